@@ -1,41 +1,53 @@
 package ovo
 
-//GetCustomerProfile : Get Customer Profile
+import "fmt"
+
+//GetCustomerProfile : Get Customer Profile, served from the profile cache when set
 func (client *Client) GetCustomerProfile(customerID string) ([]byte, error) {
-    url, err := client.getURL("customer_profile", customerID)
+    if data, ok := client.freshProfile(customerID); ok {
+        return data, nil
+    }
+
+    url, err := client.getURL(EndpointCustomerProfile, customerID)
 
     if err != nil {
         return nil, err
     }
 
-    data, errReq := client.execRequest("GET", url, nil)
+    data, errReq := client.execRequest(EndpointCustomerProfile, url, nil)
     if errReq != nil {
+        if data, ok := client.staleProfile(customerID, errReq); ok {
+            return data, nil
+        }
         return nil, errReq
     }
 
+    client.storeProfile(data, customerID)
     return data, nil
 }
 
 //GetCustomerProfileQR : Get Customer Profile (QR)
 func (client *Client) GetCustomerProfileQR(merchantID, storeID, terminalID string) ([]byte, error) {
-    url, err := client.getURL("customer_profile_qr", merchantID, storeID, terminalID)
+    url, err := client.getURL(EndpointCustomerProfileQR, merchantID, storeID, terminalID)
 
     if err != nil {
         return nil, err
     }
 
-    data, errReq := client.execRequest("GET", url, nil)
+    data, errReq := client.execRequest(EndpointCustomerProfileQR, url, nil)
     if errReq != nil {
         return nil, errReq
     }
 
+    //The customer in front of the terminal changes, only cache the profile by customer
+    client.storeProfile(data)
     return data, nil
 }
 
 //CalculatePoints : Calculate Points
 func (client *Client) CalculatePoints(customerID string, params Params) ([]byte, error) {
 
-    url, err := client.getURL("calculate_points", customerID)
+    url, err := client.getURL(EndpointCalculatePoints, customerID)
 
     if err != nil {
         return nil, err
@@ -47,11 +59,12 @@ func (client *Client) CalculatePoints(customerID string, params Params) ([]byte,
 
     buf := client.createParams(params)
 
-    data, errReq := client.execRequest("PUT", url, buf)
+    data, errReq := client.execRequest(EndpointCalculatePoints, url, buf)
 
     if errReq != nil {
         return nil, errReq
     }
+    client.InvalidateProfile(customerID)
 
     return data, nil
 }
@@ -59,7 +72,7 @@ func (client *Client) CalculatePoints(customerID string, params Params) ([]byte,
 //CreateTransaction : Create Push to Pay / Scan to Pay Transaction
 func (client *Client) CreateTransaction(customerID string, params Params) ([]byte, error) {
 
-    url, err := client.getURL("pushtopay_transaction", customerID)
+    url, err := client.getURL(EndpointPushToPayTransaction, customerID)
 
     if err != nil {
         return nil, err
@@ -71,7 +84,7 @@ func (client *Client) CreateTransaction(customerID string, params Params) ([]byt
 
     buf := client.createParams(params)
 
-    data, errReq := client.execRequest("POST", url, buf)
+    data, errReq := client.execRequest(EndpointPushToPayTransaction, url, buf)
 
     if errReq != nil {
         return nil, errReq
@@ -82,13 +95,13 @@ func (client *Client) CreateTransaction(customerID string, params Params) ([]byt
 
 //CheckTransactionStatus : Check Push to Pay / Scan To Pay Transaction Status
 func (client *Client) CheckTransactionStatus(customerID string, transactionID interface{}) ([]byte, error) {
-    url, err := client.getURL("pushtopay_transaction_status", customerID)
+    url, err := client.getURL(EndpointPushToPayTransactionStatus, customerID, fmt.Sprint(transactionID))
 
     if err != nil {
         return nil, err
     }
 
-    data, errReq := client.execRequest("GET", url, nil)
+    data, errReq := client.execRequest(EndpointPushToPayTransactionStatus, url, nil)
     if errReq != nil {
         return nil, errReq
     }
@@ -99,7 +112,7 @@ func (client *Client) CheckTransactionStatus(customerID string, transactionID in
 //VoidTransaction : Void Push To Pay / Scan To Pay Transaction
 func (client *Client) VoidTransaction(customerID, transactionID string, params Params) ([]byte, error) {
 
-    url, err := client.getURL("pushtopay_void_transaction", customerID, transactionID)
+    url, err := client.getURL(EndpointPushToPayVoidTransaction, customerID, transactionID)
 
     if err != nil {
         return nil, err
@@ -111,7 +124,7 @@ func (client *Client) VoidTransaction(customerID, transactionID string, params P
 
     buf := client.createParams(params)
 
-    data, errReq := client.execRequest("PUT", url, buf)
+    data, errReq := client.execRequest(EndpointPushToPayVoidTransaction, url, buf)
 
     if errReq != nil {
         return nil, errReq
@@ -123,7 +136,7 @@ func (client *Client) VoidTransaction(customerID, transactionID string, params P
 //CreateCustomerLinkage : Customer Creation / Linkage
 func (client *Client) CreateCustomerLinkage(customerID string, params Params) ([]byte, error) {
 
-    url, err := client.getURL("customer_linkage", customerID)
+    url, err := client.getURL(EndpointCustomerLinkage, customerID)
 
     if err != nil {
         return nil, err
@@ -135,7 +148,7 @@ func (client *Client) CreateCustomerLinkage(customerID string, params Params) ([
 
     buf := client.createParams(params)
 
-    data, errReq := client.execRequest("POST", url, buf)
+    data, errReq := client.execRequest(EndpointCustomerLinkage, url, buf)
 
     if errReq != nil {
         return nil, errReq
@@ -147,7 +160,7 @@ func (client *Client) CreateCustomerLinkage(customerID string, params Params) ([
 //CustomerAuthentication : Customer authentication, this API will push notification to customer device and open “Input Security Code” screen.
 func (client *Client) CustomerAuthentication(params Params) ([]byte, error) {
 
-    url, err := client.getURL("customer_authentication")
+    url, err := client.getURL(EndpointCustomerAuthentication)
 
     if err != nil {
         return nil, err
@@ -159,7 +172,7 @@ func (client *Client) CustomerAuthentication(params Params) ([]byte, error) {
 
     buf := client.createParams(params)
 
-    data, errReq := client.execRequest("POST", url, buf)
+    data, errReq := client.execRequest(EndpointCustomerAuthentication, url, buf)
 
     if errReq != nil {
         return nil, errReq
@@ -171,12 +184,12 @@ func (client *Client) CustomerAuthentication(params Params) ([]byte, error) {
 
 //CheckCustomerAuthenticationStatus : Check Customer Authentication Status
 func (client *Client) CheckCustomerAuthenticationStatus(authenticationID string) ([]byte, error) {
-    url, err := client.getURL("customer_authentication_status", authenticationID)
+    url, err := client.getURL(EndpointCustomerAuthenticationStatus, authenticationID)
     if err != nil {
         return nil, err
     }
 
-    data, errReq := client.execRequest("GET", url, nil)
+    data, errReq := client.execRequest(EndpointCustomerAuthenticationStatus, url, nil)
     if errReq != nil {
         return nil, errReq
     }
@@ -0,0 +1,99 @@
+package ovo
+
+import "sync"
+
+//GetCustomerProfiles : Look up the profiles of customerIDs with at most concurrency lookups in flight.
+//Results are sent in completion order, the channel is closed once every lookup is done
+func (client *Client) GetCustomerProfiles(customerIDs []string, concurrency int) <-chan ProfileResult {
+    ids := make(chan string)
+    go func() {
+        defer close(ids)
+        for _, id := range customerIDs {
+            select {
+            case ids <- id:
+            case <-client.context().Done():
+                return
+            }
+        }
+    }()
+
+    return client.StreamCustomerProfiles(ids, concurrency)
+}
+
+//StreamCustomerProfiles : Look up the profile of every customer id received from ids with at most concurrency lookups in flight.
+//The returned channel is closed once ids is closed and every lookup is done, or the context given to WithContext is done.
+//Results must be read until the channel is closed
+func (client *Client) StreamCustomerProfiles(ids <-chan string, concurrency int) <-chan ProfileResult {
+    if concurrency <= 0 {
+        concurrency = DefaultBatchConcurrency
+    }
+
+    type job struct {
+        index int
+        id    string
+    }
+    jobs := make(chan job)
+    results := make(chan ProfileResult)
+    done := client.context().Done()
+
+    go func() {
+        defer close(jobs)
+        index := 0
+        for {
+            select {
+            case id, ok := <-ids:
+                if !ok {
+                    return
+                }
+                select {
+                case jobs <- job{index, id}:
+                    index++
+                case <-done:
+                    return
+                }
+            case <-done:
+                return
+            }
+        }
+    }()
+
+    var wg sync.WaitGroup
+    for i := 0; i < concurrency; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for j := range jobs {
+                results <- client.profileResult(j.index, j.id)
+            }
+        }()
+    }
+
+    go func() {
+        wg.Wait()
+        close(results)
+    }()
+
+    return results
+}
+
+func (client *Client) profileResult(index int, customerID string) ProfileResult {
+    res := ProfileResult{Index: index, CustomerID: customerID}
+
+    res.Data, res.Err = client.GetCustomerProfile(customerID)
+    if res.Err != nil {
+        return res
+    }
+
+    r, err := client.getResponse(res.Data)
+    if err != nil {
+        res.Err = causeErr("ovo_invalid_response", client.LocaleID, err)
+        return res
+    }
+    if r.Code != Success {
+        res.Err = responseErr(r)
+        return res
+    }
+
+    res.Profile = r.Data
+    return res
+}
@@ -0,0 +1,135 @@
+package ovo
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+func TestGetCustomerProfiles(t *testing.T) {
+    var mu sync.Mutex
+    inFlight, maxInFlight := 0, 0
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        mu.Lock()
+        inFlight++
+        if inFlight > maxInFlight {
+            maxInFlight = inFlight
+        }
+        mu.Unlock()
+
+        time.Sleep(5 * time.Millisecond)
+        id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+        if id == "8005" {
+            w.WriteHeader(http.StatusNotFound)
+            w.Write([]byte(`{"status": 404, "data": [], "message": "Customer not found", "code": 0}`))
+        } else {
+            w.Write([]byte(`{"status": 200, "data": {"loyalty_id": "` + id + `", "level": "OVO"}, "message": "Success", "code": 1}`))
+        }
+
+        mu.Lock()
+        inFlight--
+        mu.Unlock()
+    }
+
+    var ids []string
+    for i := 0; i < 20; i++ {
+        ids = append(ids, fmt.Sprint(8000+i))
+    }
+
+    seen := map[int]bool{}
+    for res := range client.GetCustomerProfiles(ids, 3) {
+        seen[res.Index] = true
+        if res.CustomerID != ids[res.Index] {
+            t.Errorf("Result should carry its customer id")
+        }
+        if res.CustomerID == "8005" {
+            if res.Err == nil || res.Err.Error() != "Customer not found" {
+                t.Errorf("Unknown customer should be a per item error, got %v", res.Err)
+            }
+            continue
+        }
+        if res.Err != nil || res.Profile.LoyaltyID != res.CustomerID {
+            t.Errorf("Profile should be typed, got %+v", res)
+        }
+    }
+
+    if len(seen) != 20 {
+        t.Errorf("Every customer should have a result, got %d", len(seen))
+    }
+    if maxInFlight > 3 {
+        t.Errorf("Lookups should be limited to 3 in flight, got %d", maxInFlight)
+    }
+}
+
+func TestStreamCustomerProfilesCanceled(t *testing.T) {
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`{"status": 200, "data": {"loyalty_id": "8000"}, "message": "Success", "code": 1}`))
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    ids := make(chan string)
+    results := client.WithContext(ctx).StreamCustomerProfiles(ids, 2)
+
+    ids <- "8000"
+    <-results
+    cancel()
+
+    select {
+    case _, ok := <-results:
+        for ok {
+            _, ok = <-results
+        }
+    case <-time.After(time.Second):
+        t.Errorf("Results should be closed when the context is done")
+    }
+}
+
+func TestTokenBucket(t *testing.T) {
+    b := NewTokenBucket(1000, 2)
+    start := time.Now()
+    for i := 0; i < 12; i++ {
+        b.Wait(context.Background())
+    }
+    if time.Since(start) < 8*time.Millisecond {
+        t.Errorf("Requests after the burst should wait for the rate")
+    }
+
+    slow := NewTokenBucket(0.001, 1)
+    slow.Wait(context.Background())
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+    defer cancel()
+    if err := slow.Wait(ctx); err == nil {
+        t.Errorf("Wait should stop when the context is done")
+    }
+
+    unlimited := NewTokenBucket(0, 1)
+    done := make(chan struct{})
+    go func() {
+        for i := 0; i < 100; i++ {
+            unlimited.Wait(context.Background())
+        }
+        close(done)
+    }()
+    select {
+    case <-done:
+    case <-time.After(time.Second):
+        t.Fatalf("Rate <= 0 should not limit")
+    }
+
+    calls := 0
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        calls++
+        w.Write([]byte(testProfile))
+    }
+    client.SetRateLimiter(slow)
+    if _, err := client.WithContext(ctx).GetCustomerProfile("081208080808"); err == nil || calls != 0 {
+        t.Errorf("Request should not be sent before the limiter allows it")
+    }
+}
@@ -0,0 +1,271 @@
+//Package cassette : Record and replay OVO HTTP traffic for reproducible tests
+package cassette
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "net/http"
+    "strings"
+    "sync"
+)
+
+//Redacted : Replacement of secret values in recorded interactions
+const Redacted = "[REDACTED]"
+
+//redactedHeaders : Request headers never written to a cassette
+var redactedHeaders = []string{"hmac", "Authorization"}
+
+//ErrNoInteraction : Replayer has no recorded interaction left for the request
+var ErrNoInteraction = errors.New("cassette: no recorded interaction matches the request")
+
+//Cassette : Recorded OVO interactions
+type Cassette struct {
+    Interactions []Interaction `json:"interactions"`
+}
+
+//Interaction : Recorded request / response pair, Error is set when no response was received
+type Interaction struct {
+    Request  Request   `json:"request"`
+    Response *Response `json:"response,omitempty"`
+    Error    string    `json:"error,omitempty"`
+}
+
+//Request : Recorded request
+type Request struct {
+    Method string      `json:"method"`
+    URL    string      `json:"url"`
+    Header http.Header `json:"header,omitempty"`
+    Body   string      `json:"body,omitempty"`
+}
+
+//Response : Recorded response
+type Response struct {
+    StatusCode int         `json:"status_code"`
+    Header     http.Header `json:"header,omitempty"`
+    Body       string      `json:"body"`
+}
+
+//Load : Read cassette file
+func Load(path string) (*Cassette, error) {
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+
+    c := &Cassette{}
+    if err := json.Unmarshal(data, c); err != nil {
+        return nil, fmt.Errorf("cassette: %s: %v", path, err)
+    }
+    return c, nil
+}
+
+//Save : Write cassette file
+func (c *Cassette) Save(path string) error {
+    data, err := json.MarshalIndent(c, "", "  ")
+    if err != nil {
+        return err
+    }
+    return ioutil.WriteFile(path, data, 0644)
+}
+
+//Recorder : http.RoundTripper sending requests through Transport and saving every interaction to a cassette file
+type Recorder struct {
+    Transport http.RoundTripper
+
+    path     string
+    secrets  []string
+    mu       sync.Mutex
+    cassette *Cassette
+}
+
+//NewRecorder : Constructor for Recorder, secrets (API key, ...) are redacted wherever they appear
+func NewRecorder(path string, transport http.RoundTripper, secrets ...string) *Recorder {
+    if transport == nil {
+        transport = http.DefaultTransport
+    }
+    return &Recorder{
+        Transport: transport,
+        path:      path,
+        secrets:   secrets,
+        cassette:  &Cassette{},
+    }
+}
+
+//RoundTrip : Send the request and record it with its response
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+    body, err := readBody(&req.Body)
+    if err != nil {
+        return nil, err
+    }
+
+    i := Interaction{
+        Request: Request{
+            Method: req.Method,
+            URL:    r.redact(req.URL.String()),
+            Header: r.redactHeader(req.Header, redactedHeaders...),
+            Body:   r.redact(body),
+        },
+    }
+
+    res, err := r.Transport.RoundTrip(req)
+    if err != nil {
+        i.Error = r.redact(err.Error())
+    } else {
+        resBody, errBody := readBody(&res.Body)
+        if errBody != nil {
+            return nil, errBody
+        }
+        i.Response = &Response{
+            StatusCode: res.StatusCode,
+            Header:     r.redactHeader(res.Header),
+            Body:       r.redact(resBody),
+        }
+    }
+
+    r.mu.Lock()
+    r.cassette.Interactions = append(r.cassette.Interactions, i)
+    errSave := r.cassette.Save(r.path)
+    r.mu.Unlock()
+
+    if errSave != nil && err == nil {
+        res.Body.Close()
+        return nil, errSave
+    }
+    return res, err
+}
+
+func (r *Recorder) redact(s string) string {
+    for _, secret := range r.secrets {
+        if secret != "" {
+            s = strings.Replace(s, secret, Redacted, -1)
+        }
+    }
+    return s
+}
+
+func (r *Recorder) redactHeader(h http.Header, names ...string) http.Header {
+    out := http.Header{}
+    for k, v := range h {
+        values := make([]string, len(v))
+        for n := range v {
+            values[n] = r.redact(v[n])
+        }
+        out[k] = values
+    }
+    for _, name := range names {
+        if out.Get(name) != "" {
+            out.Set(name, Redacted)
+        }
+    }
+    return out
+}
+
+//Replayer : http.RoundTripper answering requests from a cassette, each interaction is served once in recorded order
+type Replayer struct {
+    mu       sync.Mutex
+    cassette *Cassette
+    used     []bool
+}
+
+//NewReplayer : Constructor for Replayer reading the cassette file
+func NewReplayer(path string) (*Replayer, error) {
+    c, err := Load(path)
+    if err != nil {
+        return nil, err
+    }
+    return NewCassetteReplayer(c), nil
+}
+
+//NewCassetteReplayer : Constructor for Replayer serving an already loaded cassette
+func NewCassetteReplayer(c *Cassette) *Replayer {
+    return &Replayer{cassette: c, used: make([]bool, len(c.Interactions))}
+}
+
+//RoundTrip : Answer the request with the first unused interaction having the same method, path, query and body
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+    body, err := readBody(&req.Body)
+    if err != nil {
+        return nil, err
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    for n, i := range r.cassette.Interactions {
+        if r.used[n] || !matches(i.Request, req, body) {
+            continue
+        }
+        r.used[n] = true
+
+        if i.Response == nil {
+            return nil, errors.New(i.Error)
+        }
+
+        header := http.Header{}
+        for k, v := range i.Response.Header {
+            header[k] = v
+        }
+        return &http.Response{
+            Status:        fmt.Sprintf("%d %s", i.Response.StatusCode, http.StatusText(i.Response.StatusCode)),
+            StatusCode:    i.Response.StatusCode,
+            Proto:         "HTTP/1.1",
+            ProtoMajor:    1,
+            ProtoMinor:    1,
+            Header:        header,
+            Body:          ioutil.NopCloser(strings.NewReader(i.Response.Body)),
+            ContentLength: int64(len(i.Response.Body)),
+            Request:       req,
+        }, nil
+    }
+
+    return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL.RequestURI())
+}
+
+//Remaining : Number of interactions not served yet
+func (r *Replayer) Remaining() int {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    count := 0
+    for _, used := range r.used {
+        if !used {
+            count++
+        }
+    }
+    return count
+}
+
+func matches(recorded Request, req *http.Request, body string) bool {
+    if recorded.Method != req.Method || recorded.Body != body {
+        return false
+    }
+    //Host is ignored so a cassette recorded against one environment replays against any base URL
+    idx := strings.Index(recorded.URL, "://")
+    uri := recorded.URL
+    if idx >= 0 {
+        uri = recorded.URL[idx+3:]
+        if slash := strings.Index(uri, "/"); slash >= 0 {
+            uri = uri[slash:]
+        } else {
+            uri = "/"
+        }
+    }
+    return uri == req.URL.RequestURI()
+}
+
+func readBody(body *io.ReadCloser) (string, error) {
+    if *body == nil || *body == http.NoBody {
+        return "", nil
+    }
+    data, err := ioutil.ReadAll(*body)
+    (*body).Close()
+    if err != nil {
+        return "", err
+    }
+    *body = ioutil.NopCloser(bytes.NewReader(data))
+    return string(data), nil
+}
@@ -0,0 +1,92 @@
+package cassette_test
+
+import (
+    "bytes"
+    "errors"
+    "io/ioutil"
+    "net/http"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/kh411d/ovo"
+    "github.com/kh411d/ovo/cassette"
+    "github.com/kh411d/ovo/ovotest"
+    sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+const (
+    testAppID  = "hypermart"
+    testAPIKey = "084b13ecac81e1a8caf1775ad02bd5fa40e7219c8956dba11429a497a0e4cd89"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+    dir, err := ioutil.TempDir("", "cassette")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    path := filepath.Join(dir, "profile.json")
+
+    srv := ovotest.NewServer(testAppID, testAPIKey)
+    customer := srv.AddCustomer(ovotest.Customer{Phone: "081234567890", Fullname: "Budi"})
+
+    client := ovo.New(srv.URL, testAPIKey, testAppID, "1")
+    client.SetHTTPClient(&http.Client{Transport: cassette.NewRecorder(path, nil, testAPIKey)})
+    recorded, err := client.GetCustomerProfile(customer.LoyaltyID)
+    if err != nil {
+        t.Fatalf("Recording should not error, got %s", err)
+    }
+    srv.Close()
+
+    data, _ := ioutil.ReadFile(path)
+    if bytes.Contains(data, []byte(client.Hmac)) || bytes.Contains(data, []byte(testAPIKey)) {
+        t.Errorf("Cassette must not contain hmac nor api key")
+    }
+
+    replayer, err := cassette.NewReplayer(path)
+    if err != nil {
+        t.Fatalf("Cassette should be loaded, got %s", err)
+    }
+    client.SetHTTPClient(&http.Client{Transport: replayer})
+
+    replayed, err := client.GetCustomerProfile(customer.LoyaltyID)
+    if err != nil {
+        t.Fatalf("Replay should not error, got %s", err)
+    }
+    if !bytes.Equal(recorded, replayed) {
+        t.Errorf("Replayed response should be the recorded one")
+    }
+    if replayer.Remaining() != 0 {
+        t.Errorf("Every interaction should be served")
+    }
+
+    if _, err := client.GetCustomerProfile(customer.LoyaltyID); !errors.Is(err, cassette.ErrNoInteraction) {
+        t.Errorf("Interaction must be served once, got %v", err)
+    }
+}
+
+func TestReplayArrayData(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, nil, "081234567890", "A00000001", 0, testAppID)
+    mock.ExpectQuery(`SELECT customer_id`).WillReturnRows(rows)
+
+    replayer, err := cassette.NewReplayer("testdata/auth_status_array_data.json")
+    if err != nil {
+        t.Fatalf("Cassette should be loaded, got %s", err)
+    }
+
+    client := ovo.New("http://localhost/loyalty-back", testAPIKey, testAppID, "1")
+    client.SetHTTPClient(&http.Client{Transport: replayer})
+    mmsdk := client.GetMMsdk(db)
+
+    _, err = mmsdk.CheckOvoStatus(12345)
+    if err == nil || err.Error() != ovo.TErr("ovo_retry_verification", "en").Error() {
+        t.Errorf("Array data should be read as unauthenticated, got %v", err)
+    }
+}
@@ -0,0 +1,289 @@
+package ovo
+
+import (
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "database/sql"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "strings"
+)
+
+//phoneCipherPrefix : Prefix of encrypted values, enc:<key id>:<base64 nonce and sealed phone>
+const phoneCipherPrefix = "enc:"
+
+var errUnknownPhoneKey = errors.New("ovo: unknown phone encryption key")
+
+//CurrentKey : Key of Current
+func (k StaticKeyProvider) CurrentKey() (string, []byte, error) {
+    key, err := k.Key(k.Current)
+    return k.Current, key, err
+}
+
+//Key : Key of id
+func (k StaticKeyProvider) Key(id string) ([]byte, error) {
+    if key, ok := k.Keys[id]; ok {
+        return key, nil
+    }
+    return nil, errUnknownPhoneKey
+}
+
+//Encrypt : Encrypt plain with the current key
+func (p *PhoneCipher) Encrypt(plain string) (string, error) {
+    id, key, err := p.Keys.CurrentKey()
+    if err != nil {
+        return "", err
+    }
+    if strings.Contains(id, ":") {
+        return "", errors.New("ovo: phone encryption key id must not contain ':'")
+    }
+
+    aead, err := newAEAD(key)
+    if err != nil {
+        return "", err
+    }
+
+    nonce := make([]byte, aead.NonceSize())
+    if _, err = rand.Read(nonce); err != nil {
+        return "", err
+    }
+    sealed := aead.Seal(nonce, nonce, []byte(plain), []byte(id))
+
+    return phoneCipherPrefix + id + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+//Decrypt : Decrypt value written by Encrypt with any key known by Keys, value without the enc: prefix is returned as is
+func (p *PhoneCipher) Decrypt(value string) (string, error) {
+    id, data, ok := splitCipherText(value)
+    if !ok {
+        return value, nil
+    }
+
+    key, err := p.Keys.Key(id)
+    if err != nil {
+        return "", err
+    }
+    aead, err := newAEAD(key)
+    if err != nil {
+        return "", err
+    }
+
+    sealed, err := base64.RawStdEncoding.DecodeString(data)
+    if err != nil || len(sealed) < aead.NonceSize() {
+        return "", errors.New("ovo: malformed encrypted phone")
+    }
+    plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+    if err != nil {
+        return "", err
+    }
+
+    return string(plain), nil
+}
+
+//Index : Blind index of a phone, HMAC-SHA256 of its E.164 form
+func (p *PhoneCipher) Index(e164 string) string {
+    mac := hmac.New(sha256.New, p.IndexKey)
+    mac.Write([]byte(e164))
+    return hex.EncodeToString(mac.Sum(nil))
+}
+
+//isCurrent : Check if value is encrypted with the current key
+func (p *PhoneCipher) isCurrent(value string) (bool, error) {
+    id, _, ok := splitCipherText(value)
+    if !ok {
+        return false, nil
+    }
+    current, _, err := p.Keys.CurrentKey()
+    return id == current, err
+}
+
+func splitCipherText(value string) (id, data string, ok bool) {
+    if !strings.HasPrefix(value, phoneCipherPrefix) {
+        return "", "", false
+    }
+    parts := strings.SplitN(value[len(phoneCipherPrefix):], ":", 2)
+    if len(parts) != 2 {
+        return "", "", false
+    }
+    return parts[0], parts[1], true
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+    block, err := aes.NewCipher(key)
+    if err != nil {
+        return nil, err
+    }
+    return cipher.NewGCM(block)
+}
+
+//sealPhone : Value of a phone column, encrypted when PhoneCipher is set
+func (c *MatahariMall) sealPhone(p string) (string, error) {
+    if c.PhoneCipher == nil || p == "" {
+        return p, nil
+    }
+    s, err := c.PhoneCipher.Encrypt(p)
+    if err != nil {
+        return "", causeErr("ovo_phone_cipher", c.API.LocaleID, err)
+    }
+    return s, nil
+}
+
+//openPhone : Phone of a phone column, plaintext values written before PhoneCipher was set are returned as is
+func (c *MatahariMall) openPhone(s string) (string, error) {
+    if c.PhoneCipher == nil {
+        return s, nil
+    }
+    p, err := c.PhoneCipher.Decrypt(s)
+    if err != nil {
+        return "", causeErr("ovo_phone_cipher", c.API.LocaleID, err)
+    }
+    return p, nil
+}
+
+//ReencryptPhones : Encrypt plaintext phones and phones written with a previous key using the current key of PhoneCipher,
+//in customer_ovo, customer_ovo_unlink, customer_ovo_relink and customer_ovo_history, batchSize rows per query. ovo_phone_lookup of rewritten rows becomes the blind index.
+//Run it after enabling PhoneCipher and after every key rotation, the previous key must stay in the KeyProvider until it returns
+func (c *MatahariMall) ReencryptPhones(batchSize int) (int, error) {
+    if c.PhoneCipher == nil {
+        return 0, TErr("ovo_phone_cipher", c.API.LocaleID)
+    }
+    if batchSize <= 0 {
+        batchSize = DefaultBackfillBatch
+    }
+
+    var updated int
+    tables := []struct {
+        table, key string
+        columns    []string
+        lookup     bool
+    }{
+        {"customer_ovo", "customer_id", []string{"ovo_phone"}, true},
+        {"customer_ovo_unlink", "id", []string{"ovo_phone"}, true},
+        {"customer_ovo_relink", "customer_id", []string{"ovo_phone"}, true},
+        {"customer_ovo_history", "id", []string{"old_ovo_phone", "new_ovo_phone"}, false},
+    }
+    for _, t := range tables {
+        n, err := c.reencryptPhones(t.table, t.key, t.columns, t.lookup, batchSize)
+        updated += n
+        if err != nil {
+            return updated, err
+        }
+    }
+
+    return updated, nil
+}
+
+func (c *MatahariMall) reencryptPhones(table, key string, columns []string, lookup bool, batchSize int) (int, error) {
+    q := `SELECT ` + key + `, ` + strings.Join(columns, ", ") + `
+            FROM ` + table + `
+           WHERE ` + key + ` > ?
+           ORDER BY ` + key + `
+           LIMIT ?`
+    sqlUpdate := `UPDATE ` + table + ` SET ` + strings.Join(columns, " = ?, ") + ` = ?`
+    if lookup {
+        sqlUpdate += `, ovo_phone_lookup = ?`
+    }
+    sqlUpdate += ` WHERE ` + key + ` = ?`
+
+    var last int64
+    var updated int
+    for {
+        end := c.sqlSpan("SELECT", table)
+        rows, err := c.DB.Query(q, last, batchSize)
+        if err != nil {
+            end(err)
+            return updated, wrapErr(err, ErrCategoryTransport)
+        }
+
+        var batch [][]interface{}
+        for rows.Next() {
+            var id int64
+            values := make([]sql.NullString, len(columns))
+            dest := []interface{}{&id}
+            for i := range values {
+                dest = append(dest, &values[i])
+            }
+            if err = rows.Scan(dest...); err != nil {
+                rows.Close()
+                end(err)
+                return updated, wrapErr(err, ErrCategoryTransport)
+            }
+
+            row := []interface{}{id}
+            for _, v := range values {
+                row = append(row, v.String)
+            }
+            batch = append(batch, row)
+        }
+        err = rows.Err()
+        rows.Close()
+        end(err)
+        if err != nil {
+            return updated, wrapErr(err, ErrCategoryTransport)
+        }
+
+        for _, row := range batch {
+            last = row[0].(int64)
+
+            args, changed, err := c.reencryptRow(row[1:], lookup)
+            if err != nil {
+                return updated, err
+            }
+            if !changed {
+                continue
+            }
+
+            end := c.sqlSpan("UPDATE", table)
+            _, err = c.DB.Exec(sqlUpdate, append(args, last)...)
+            end(err)
+            if err != nil {
+                return updated, wrapErr(err, ErrCategoryTransport)
+            }
+            updated++
+        }
+
+        if len(batch) < batchSize {
+            return updated, nil
+        }
+    }
+}
+
+//reencryptRow : Column values encrypted with the current key followed by the blind index of the first one when lookup is set
+func (c *MatahariMall) reencryptRow(values []interface{}, lookup bool) ([]interface{}, bool, error) {
+    var args []interface{}
+    var changed bool
+    var first string
+
+    for i, v := range values {
+        value := v.(string)
+        plain, err := c.openPhone(value)
+        if err != nil {
+            return nil, false, err
+        }
+        if i == 0 {
+            first = plain
+        }
+
+        current, err := c.PhoneCipher.isCurrent(value)
+        if err != nil {
+            return nil, false, causeErr("ovo_phone_cipher", c.API.LocaleID, err)
+        }
+        if value != "" && !current {
+            changed = true
+            if value, err = c.sealPhone(plain); err != nil {
+                return nil, false, err
+            }
+        }
+        args = append(args, value)
+    }
+
+    if lookup {
+        args = append(args, nullString(c.phoneLookup(first)))
+    }
+
+    return args, changed, nil
+}
@@ -0,0 +1,141 @@
+package ovo
+
+import (
+    "database/sql/driver"
+    "strings"
+    "testing"
+
+    sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type encryptedPhone struct {
+    cipher *PhoneCipher
+    phone  string
+}
+
+func (a encryptedPhone) Match(v driver.Value) bool {
+    s, ok := v.(string)
+    if !ok || !strings.HasPrefix(s, "enc:k2:") {
+        return false
+    }
+    p, err := a.cipher.Decrypt(s)
+    return err == nil && p == a.phone
+}
+
+func testPhoneCipher() *PhoneCipher {
+    return &PhoneCipher{
+        Keys: StaticKeyProvider{
+            Current: "k2",
+            Keys: map[string][]byte{
+                "k1": []byte("0123456789abcdef0123456789abcdef"),
+                "k2": []byte("fedcba9876543210fedcba9876543210"),
+            },
+        },
+        IndexKey: []byte("index"),
+    }
+}
+
+func TestPhoneCipher(t *testing.T) {
+    pc := testPhoneCipher()
+
+    enc, err := pc.Encrypt("081234567890")
+    if err != nil || !strings.HasPrefix(enc, "enc:k2:") {
+        t.Fatalf("This should encrypt with the current key, got %s %v", enc, err)
+    }
+    if enc2, _ := pc.Encrypt("081234567890"); enc2 == enc {
+        t.Errorf("Encryption should not be deterministic")
+    }
+    if p, err := pc.Decrypt(enc); err != nil || p != "081234567890" {
+        t.Errorf("This should decrypt, got %s %v", p, err)
+    }
+    if p, _ := pc.Decrypt("081234567890"); p != "081234567890" {
+        t.Errorf("Plaintext should be returned as is")
+    }
+
+    old := &PhoneCipher{Keys: StaticKeyProvider{Current: "k1", Keys: pc.Keys.(StaticKeyProvider).Keys}}
+    enc, _ = old.Encrypt("081234567890")
+    if p, err := pc.Decrypt(enc); err != nil || p != "081234567890" {
+        t.Errorf("Previous key should still decrypt, got %s %v", p, err)
+    }
+
+    if _, err := pc.Decrypt(strings.Replace(enc, "enc:k1:", "enc:k2:", 1)); err == nil {
+        t.Errorf("Key id is authenticated, changing it should fail")
+    }
+    if _, err := pc.Decrypt("enc:k3:AAAA"); err == nil {
+        t.Errorf("Unknown key should fail")
+    }
+
+    mmsdk := (&Client{LocaleID: "en"}).GetMMsdk(nil)
+    mmsdk.PhoneCipher = pc
+    if _, err := mmsdk.openPhone("enc:k3:AAAA"); GetErrCategory(err) != ErrCategoryTransport || IsTemporary(err) {
+        t.Errorf("Cipher failure should be a non temporary transport error, got %v", err)
+    }
+
+    if pc.Index("+6281234567890") != pc.Index("+6281234567890") || len(pc.Index("+6281234567890")) != 64 {
+        t.Errorf("Blind index should be a deterministic hex HMAC-SHA256")
+    }
+}
+
+func TestSaveToDatabaseEncrypted(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    client := new(Client)
+    client.LocaleID = "en"
+    client.AppID = "hypermart"
+    mmsdk := client.GetMMsdk(db)
+    mmsdk.PhoneCipher = testPhoneCipher()
+
+    phone := encryptedPhone{mmsdk.PhoneCipher, "081282828282"}
+    index := mmsdk.PhoneCipher.Index("+6281282828282")
+    mock.ExpectBegin()
+    mock.ExpectExec(`INSERT INTO customer_ovo`).WithArgs(1234, phone, index, "234", "hypermart").WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WithArgs(1234, "insert", "", "", "", phone, "", "234", 0, 0, "", "hypermart", "").WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    mmsdk.OvoInfo = &CustomerOvo{OvoAuthID: "234"}
+    mmsdk.OvoReq = &Request{CustomerID: 1234, Phone: "081282828282"}
+
+    if err := mmsdk.saveToDatabase(); err != nil {
+        t.Errorf("Should not be error, got %s", err)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("Phone should be stored encrypted with its blind index: %s", err)
+    }
+}
+
+func TestReencryptPhones(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    client := new(Client)
+    client.LocaleID = "en"
+    mmsdk := client.GetMMsdk(db)
+    mmsdk.PhoneCipher = testPhoneCipher()
+
+    current, _ := mmsdk.PhoneCipher.Encrypt("081234567891")
+    phone := encryptedPhone{mmsdk.PhoneCipher, "081234567890"}
+
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_phone"}).AddRow(1, "081234567890").AddRow(2, current)
+    mock.ExpectQuery(`SELECT customer_id, ovo_phone FROM customer_ovo`).WithArgs(0, 10).WillReturnRows(rows)
+    mock.ExpectExec(`UPDATE customer_ovo SET ovo_phone = \?, ovo_phone_lookup = \? WHERE customer_id = \?`).WithArgs(phone, mmsdk.PhoneCipher.Index("+6281234567890"), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectQuery(`SELECT id, ovo_phone FROM customer_ovo_unlink`).WithArgs(0, 10).WillReturnRows(sqlmock.NewRows([]string{"id", "ovo_phone"}))
+    mock.ExpectQuery(`SELECT customer_id, ovo_phone FROM customer_ovo_relink`).WithArgs(0, 10).WillReturnRows(sqlmock.NewRows([]string{"customer_id", "ovo_phone"}))
+    rows = sqlmock.NewRows([]string{"id", "old_ovo_phone", "new_ovo_phone"}).AddRow(5, "", "081234567890")
+    mock.ExpectQuery(`SELECT id, old_ovo_phone, new_ovo_phone FROM customer_ovo_history`).WithArgs(0, 10).WillReturnRows(rows)
+    mock.ExpectExec(`UPDATE customer_ovo_history SET old_ovo_phone = \?, new_ovo_phone = \? WHERE id = \?`).WithArgs("", phone, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+
+    n, err := mmsdk.ReencryptPhones(10)
+    if err != nil || n != 2 {
+        t.Errorf("This should rewrite 2 rows, got %d %v", n, err)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("there were unfulfilled expectations: %s", err)
+    }
+}
@@ -10,14 +10,12 @@ import (
     "net/http"
     "net/http/httptest"
     "net/url"
-    "regexp"
-    "strings"
     "time"
 )
 
 //New : Constructor for OVO Client / App
 func New(baseURL, apiKey, appID, merchantID string) *Client {
-    random := time.Now().Format("20060102150405")
+    random := time.Now().Format(RandomLayout)
 
     c := &Client{
         BaseURL:    baseURL,
@@ -26,6 +24,7 @@ func New(baseURL, apiKey, appID, merchantID string) *Client {
         MerchantID: merchantID,
         Random:     random,
         LocaleID:   "en",
+        flight:     &flightGroup{},
     }
 
     c.setAuthorizationKey()
@@ -39,36 +38,47 @@ func (client *Client) SetLocale(localeID string) {
     client.LocaleID = localeID
 }
 
+//SetHTTPClient : Setting http client used to call OVO, http.DefaultClient when not set
+func (client *Client) SetHTTPClient(httpClient *http.Client) {
+    client.httpClient = httpClient
+}
+
 func (client *Client) setErrMessage(data map[string]map[string]string) {
     ErrMessage = data
 }
 
 func (client *Client) setAuthorizationKey() {
-    stringToSign := []byte(client.AppID + client.Random)
-    h := hmac.New(sha256.New, []byte(client.APIKey))
-    h.Write(stringToSign)
-    client.Hmac = fmt.Sprintf("%x", h.Sum(nil))
+    client.Hmac = signature(client.AppID, client.Random, client.APIKey)
+}
+
+//signature : HMAC-SHA256 of app id and random, keyed with the api key
+func signature(appID, random, apiKey string) string {
+    h := hmac.New(sha256.New, []byte(apiKey))
+    h.Write([]byte(appID + random))
+    return fmt.Sprintf("%x", h.Sum(nil))
 }
 
 func (client *Client) newRequest(method string, url string, body *bytes.Buffer) (*http.Request, error) {
 
+    //Cancelling the context of the client cancels the request
+    ctx := client.context()
     var req *http.Request
     var err error
     if body == nil {
-        req, err = http.NewRequest(method, url, nil)
+        req, err = http.NewRequestWithContext(ctx, method, url, nil)
     } else {
-        req, err = http.NewRequest(method, url, body)
+        req, err = http.NewRequestWithContext(ctx, method, url, body)
     }
     if err != nil {
-        return nil, err
+        return nil, wrapErr(err, ErrCategoryValidation)
     }
 
     //Override request
     if client.httpHandler != nil {
         if body == nil {
-            req = httptest.NewRequest(method, url, nil)
+            req = httptest.NewRequest(method, url, nil).WithContext(ctx)
         } else {
-            req = httptest.NewRequest(method, url, body)
+            req = httptest.NewRequest(method, url, body).WithContext(ctx)
         }
     }
 
@@ -76,34 +86,35 @@ func (client *Client) newRequest(method string, url string, body *bytes.Buffer)
         req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
     }
 
-    req.Header.Add("app-id", client.AppID)
-    req.Header.Add("random", client.Random)
-    req.Header.Add("hmac", client.Hmac)
-
-    //Setup ResponseWriter recorder
-    if client.httpHandler != nil {
-        client.wRes = httptest.NewRecorder()
-        client.httpHandler(client.wRes, req)
+    //Sign every request with the current time so it passes OVO freshness check
+    now := time.Now()
+    appID, apiKey, err := client.signingKey(now)
+    if err != nil {
+        return nil, err
     }
+    random := now.Format(RandomLayout)
+    req.Header.Add("app-id", appID)
+    req.Header.Add("random", random)
+    req.Header.Add("hmac", signature(appID, random, apiKey))
 
     return req, nil
 }
 
 func (client *Client) sendRequest(request *http.Request) (response *http.Response, data []byte, err error) {
 
-    if client.httpHandler != nil {
-        response = client.wRes.Result()
-    } else {
-        response, err = http.DefaultClient.Do(request)
-    }
+    response, err = client.doer().Do(request)
+
+    //Request may have reached OVO, only resend when the method is idempotent
+    idempotent := request.Method != "POST"
 
     if err != nil {
-        err = TErr("ovo_unavailable_service", client.LocaleID)
+        err = transportErr("ovo_unavailable_service", client.LocaleID, err, idempotent)
         return
     }
 
     if response.StatusCode >= http.StatusInternalServerError {
-        err = TErr("ovo_unavailable_service", client.LocaleID)
+        response.Body.Close()
+        err = transportErr("ovo_unavailable_service", client.LocaleID, nil, idempotent)
         return
     }
 
@@ -111,14 +122,45 @@ func (client *Client) sendRequest(request *http.Request) (response *http.Respons
     _, err = io.Copy(buf, response.Body)
     response.Body.Close()
     if err != nil {
-        err = TErr("ovo_invalid_response", client.LocaleID)
+        err = transportErr("ovo_invalid_response", client.LocaleID, err, idempotent)
     }
 
     data = buf.Bytes()
     return
 }
 
-func (client *Client) execRequest(method string, url string, body *bytes.Buffer) (data []byte, err error) {
+func (client *Client) getHTTPClient() *http.Client {
+    if client.httpClient != nil {
+        return client.httpClient
+    }
+    return http.DefaultClient
+}
+
+func (client *Client) execRequest(endpoint string, url string, body *bytes.Buffer) (data []byte, err error) {
+
+    e, ok := LookupEndpoint(endpoint)
+    if !ok {
+        return nil, TErr("ovo_unidentified_request", client.LocaleID)
+    }
+
+    if e.Method == "GET" && client.flight != nil {
+        ctx := client.context()
+        shared := client.WithContext(detachedContext{parent: ctx})
+        return client.flight.do(ctx, url, func() ([]byte, error) {
+            return shared.doRequest(e, url, nil)
+        })
+    }
+    return client.doRequest(e, url, body)
+}
+
+func (client *Client) doRequest(e Endpoint, url string, body *bytes.Buffer) (data []byte, err error) {
+    endpoint, method := e.Name, e.Method
+
+    if client.limiter != nil {
+        if err := client.limiter.Wait(client.context()); err != nil {
+            return nil, wrapErr(err, ErrCategoryTransport)
+        }
+    }
 
     req, errReq := client.newRequest(method, url, body)
 
@@ -126,11 +168,29 @@ func (client *Client) execRequest(method string, url string, body *bytes.Buffer)
         return nil, errReq
     }
 
-    _, data, errResp := client.sendRequest(req)
+    ctx, span, end := client.startSpan("ovo."+endpoint,
+        Attr("ovo.endpoint", endpoint),
+        Attr("http.method", method),
+    )
+    client.injectTrace(ctx, req.Header)
+
+    start := time.Now()
+    resp, data, errResp := client.sendRequest(req)
+    client.observeRequest(endpoint, method, resp, data, time.Since(start))
+    if resp != nil && client.tracer != nil {
+        span.SetAttributes(Attr("http.status_code", resp.StatusCode), Attr("ovo.code", responseCode(data)))
+    }
+    end(errResp)
 
     if errResp != nil {
+        client.log().Warn("ovo request failed", "endpoint", endpoint, "method", method, "url", url, "duration", time.Since(start), "error", errResp)
         return nil, errResp
     }
+    if resp.StatusCode != e.SuccessStatus {
+        client.log().Warn("ovo request unexpected status", "endpoint", endpoint, "method", method, "url", url, "status", resp.StatusCode, "expected", e.SuccessStatus, "duration", time.Since(start))
+        return nil, client.statusErr(method, resp.StatusCode, data)
+    }
+    client.log().Debug("ovo request", "endpoint", endpoint, "method", method, "url", url, "status", resp.StatusCode, "duration", time.Since(start))
     return data, nil
 }
 
@@ -147,26 +207,23 @@ func (client *Client) createParams(params Params) *bytes.Buffer {
     return buf
 }
 
-func (client *Client) getURL(name string, params ...string) (string, error) {
-
-    re := regexp.MustCompile(":[a-zA-Z0-9_]+")
-    vars := re.FindAllString(domainMap[name], -1)
-    if len(params) != len(vars) {
-        return "", TErr("ovo_unidentified_request", client.LocaleID)
-    }
-
-    path, ok := domainMap[name]
-    if !ok {
-        return "", TErr("ovo_unidentified_request", client.LocaleID)
+//statusErr : Error of a response not having the endpoint success status, classified by the OVO code it holds,
+//or by the status when the body is not an OVO response (e.g. html error page)
+func (client *Client) statusErr(method string, status int, data []byte) error {
+    r, err := client.getResponse(data)
+    if err != nil || r.Code == Success {
+        e := causeErr("ovo_invalid_response", client.LocaleID, err).(*CustomError)
+        //A throttled request was not processed, a failing OVO may have processed a POST
+        if status == http.StatusTooManyRequests || status >= http.StatusInternalServerError {
+            e.temporary = true
+            e.retryable = status == http.StatusTooManyRequests || method != "POST"
+        }
+        return e
     }
-    counter := 0
-    for _, v := range vars {
-        path = strings.Replace(path, v, params[counter], 2)
-        counter = counter + 1
+    if r.Status == 0 {
+        r.Status = status
     }
-
-    url := client.BaseURL + path
-    return url, nil
+    return responseErr(r)
 }
 
 func (client *Client) getResponse(data []byte) (Response, error) {
@@ -179,7 +236,7 @@ func (client *Client) getResponse(data []byte) (Response, error) {
             r.Data = ResponseData{}
             return r, nil
         }
-        return r, err
+        return r, wrapErr(err, ErrCategoryTransport)
     }
 
     return r, nil
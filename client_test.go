@@ -1,6 +1,7 @@
 package ovo
 
 import (
+    "context"
     "crypto/hmac"
     "crypto/sha256"
     "fmt"
@@ -34,24 +35,24 @@ func TestGetURL(t *testing.T) {
         switch len(vars) {
         case 1:
             if _, err := client.getURL(k, randNumber); err != nil {
-                t.Errorf(k + " domain map is missing")
+                t.Errorf("%s domain map is missing", k)
             }
             if _, err := client.getURL(k, randNumber, randNumber); err.Error() != TErr("ovo_unidentified_request", client.LocaleID).Error() {
-                t.Errorf(k + "params number must be invalid")
+                t.Errorf("%s params number must be invalid", k)
             }
         case 2:
             if _, err := client.getURL(k, randNumber, randNumber); err != nil {
-                t.Errorf(k + " domain map is missing")
+                t.Errorf("%s domain map is missing", k)
             }
             if _, err := client.getURL(k, randNumber); err.Error() != TErr("ovo_unidentified_request", client.LocaleID).Error() {
-                t.Errorf(k + "params number must be invalid")
+                t.Errorf("%s params number must be invalid", k)
             }
         case 3:
             if _, err := client.getURL(k, randNumber, randNumber, randNumber); err != nil {
-                t.Errorf(k + " domain map is missing")
+                t.Errorf("%s domain map is missing", k)
             }
             if _, err := client.getURL(k, randNumber, randNumber); err.Error() != TErr("ovo_unidentified_request", client.LocaleID).Error() {
-                t.Errorf(k + "params number must be invalid")
+                t.Errorf("%s params number must be invalid", k)
             }
         }
     }
@@ -84,7 +85,7 @@ func TestNewRequest(t *testing.T) {
     for _, v := range methods {
         httpReq, err := client.newRequest(v, "http://testing.com", nil)
         if err != nil {
-            t.Errorf(err.Error())
+            t.Error(err)
         }
         if httpReq.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
             t.Errorf("Invalid content type")
@@ -93,7 +94,7 @@ func TestNewRequest(t *testing.T) {
 
     httpReq, err := client.newRequest("GET", "http://testing.com", nil)
     if err != nil {
-        t.Errorf(err.Error())
+        t.Error(err)
     }
 
     header := httpReq.Header
@@ -108,6 +109,14 @@ func TestNewRequest(t *testing.T) {
         t.Errorf("hmac not found")
     }
 
+    ctx := context.WithValue(context.Background(), ctxKey{}, "checkout")
+    httpReq, err = client.WithContext(ctx).newRequest("GET", "http://testing.com", nil)
+    if err != nil {
+        t.Error(err)
+    }
+    if httpReq.Context() != ctx {
+        t.Errorf("Request should carry the context of the client")
+    }
 }
 
 func TestGetResponse(t *testing.T) {
@@ -128,12 +137,12 @@ func TestExecRequest503(t *testing.T) {
         //io.WriteString(w, "<html><body>Hello World!</body></html>")
     }
 
-    _, err := client.execRequest("GET", "http://apapunitu.com", nil)
+    _, err := client.execRequest("customer_profile", "http://apapunitu.com", nil)
     if err == nil {
         t.Errorf("Should error when service 503")
     }
     if err != nil && err.Error() != TErr("ovo_unavailable_service", client.LocaleID).Error() {
-        t.Errorf("Should return Err: " + TErr("ovo_unavailable_service", client.LocaleID).Error())
+        t.Errorf("Should return Err: %s", TErr("ovo_unavailable_service", client.LocaleID))
     }
 
 }
@@ -0,0 +1,219 @@
+//Command ovo-sim : Standalone OVO loyalty-back simulator for local development
+//
+//The OVO api is served under -base-path, point ovo.New to http://<addr><base-path>.
+//Pending authentications and transactions are resolved through the admin endpoints:
+//
+//    GET  /admin/authentications[?status=pending]
+//    POST /admin/authentications/{id}/approve
+//    POST /admin/authentications/{id}/reject
+//    GET  /admin/transactions[?status=pending]
+//    POST /admin/transactions/{id}/approve
+//    POST /admin/transactions/{id}/reject
+//    GET  /admin/customers/{loyalty id or phone}
+//    POST /admin/customers                       body: ovotest.Customer as JSON
+//    POST /admin/outcome?value=approve|reject|timeout|unavailable
+//    POST /admin/fail?status=503&n=1
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io/ioutil"
+    "log"
+    "math/rand"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/kh411d/ovo"
+    "github.com/kh411d/ovo/ovotest"
+)
+
+var outcomes = map[string]ovotest.Outcome{
+    "approve":     ovotest.Approve,
+    "reject":      ovotest.Reject,
+    "timeout":     ovotest.Timeout,
+    "unavailable": ovotest.Unavailable,
+}
+
+func main() {
+    addr := flag.String("addr", ":8080", "listen address")
+    basePath := flag.String("base-path", "/loyalty-back", "path prefix of the OVO api")
+    appID := flag.String("app-id", "ovo-sim", "app-id accepted by the simulator")
+    apiKey := flag.String("api-key", "ovo-sim-key", "api key used to verify the hmac header")
+    latency := flag.Duration("latency", 0, "delay added to every OVO api response")
+    maxSkew := flag.Duration("max-skew", ovo.DefaultMaxSkew, "accepted age of the random header, 0 disables the check")
+    failRate := flag.Float64("fail-rate", 0, "ratio (0-1) of OVO api requests answered with -fail-status")
+    failStatus := flag.Int("fail-status", http.StatusServiceUnavailable, "HTTP status of injected failures")
+    outcome := flag.String("outcome", "timeout", "default customer action: approve, reject, timeout or unavailable")
+    seed := flag.String("customers", "", "JSON file with the list of customers to register at start")
+    flag.Parse()
+
+    o, ok := outcomes[*outcome]
+    if !ok {
+        log.Fatalf("unknown outcome %q", *outcome)
+    }
+
+    h := ovotest.NewHandler(*appID, *apiKey)
+    h.SetLatency(*latency)
+    h.SetMaxSkew(*maxSkew)
+    h.SetOutcome(o)
+
+    if *seed != "" {
+        if err := loadCustomers(h, *seed); err != nil {
+            log.Fatal(err)
+        }
+    }
+
+    mux := http.NewServeMux()
+    prefix := strings.TrimRight(*basePath, "/")
+    mux.Handle(prefix+"/", http.StripPrefix(prefix, injectFailures(h, *failRate, *failStatus)))
+    mux.Handle("/admin/", &admin{h})
+
+    log.Printf("ovo-sim listening on %s, base URL http://localhost%s%s", *addr, *addr, prefix)
+    log.Fatal(http.ListenAndServe(*addr, logRequests(mux)))
+}
+
+func loadCustomers(h *ovotest.Handler, path string) error {
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        return err
+    }
+
+    var customers []ovotest.Customer
+    if err := json.Unmarshal(data, &customers); err != nil {
+        return fmt.Errorf("%s: %v", path, err)
+    }
+
+    for _, c := range customers {
+        c = h.AddCustomer(c)
+        log.Printf("customer %s registered with phone %s", c.LoyaltyID, c.Phone)
+    }
+    return nil
+}
+
+func injectFailures(next http.Handler, rate float64, status int) http.Handler {
+    if rate <= 0 {
+        return next
+    }
+    //rand.Rand is not safe for concurrent use, requests are served concurrently
+    var mu sync.Mutex
+    rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        mu.Lock()
+        fail := rnd.Float64() < rate
+        mu.Unlock()
+        if fail {
+            w.WriteHeader(status)
+            fmt.Fprintf(w, `{"status":%d,"message":%q}`, status, http.StatusText(status))
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
+
+func logRequests(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        start := time.Now()
+        next.ServeHTTP(w, r)
+        log.Printf("%s %s %s", r.Method, r.URL.Path, time.Since(start))
+    })
+}
+
+type admin struct {
+    h *ovotest.Handler
+}
+
+func (a *admin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/"), "/"), "/")
+
+    switch {
+    case r.Method == "GET" && len(parts) == 1 && parts[0] == "authentications":
+        writeJSON(w, http.StatusOK, a.h.Authentications(statusFilter(r)...))
+    case r.Method == "GET" && len(parts) == 1 && parts[0] == "transactions":
+        writeJSON(w, http.StatusOK, a.h.Transactions(statusFilter(r)...))
+    case r.Method == "POST" && len(parts) == 3 && (parts[0] == "authentications" || parts[0] == "transactions"):
+        a.resolve(w, parts[0], parts[1], parts[2])
+    case r.Method == "GET" && len(parts) == 2 && parts[0] == "customers":
+        c, ok := a.h.Customer(parts[1])
+        if !ok {
+            writeJSON(w, http.StatusNotFound, "customer not found")
+            return
+        }
+        writeJSON(w, http.StatusOK, c)
+    case r.Method == "POST" && len(parts) == 1 && parts[0] == "customers":
+        var c ovotest.Customer
+        if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+            writeJSON(w, http.StatusBadRequest, err.Error())
+            return
+        }
+        writeJSON(w, http.StatusCreated, a.h.AddCustomer(c))
+    case r.Method == "POST" && len(parts) == 1 && parts[0] == "outcome":
+        o, ok := outcomes[r.URL.Query().Get("value")]
+        if !ok {
+            writeJSON(w, http.StatusBadRequest, "unknown outcome")
+            return
+        }
+        a.h.SetOutcome(o)
+        writeJSON(w, http.StatusOK, r.URL.Query().Get("value"))
+    case r.Method == "POST" && len(parts) == 1 && parts[0] == "fail":
+        status, err := strconv.Atoi(r.URL.Query().Get("status"))
+        if err != nil {
+            status = http.StatusServiceUnavailable
+        }
+        n, err := strconv.Atoi(r.URL.Query().Get("n"))
+        if err != nil {
+            n = 1
+        }
+        a.h.FailNext(status, n)
+        writeJSON(w, http.StatusOK, fmt.Sprintf("next %d requests answered with %d", n, status))
+    default:
+        writeJSON(w, http.StatusNotFound, "unknown admin endpoint")
+    }
+}
+
+func (a *admin) resolve(w http.ResponseWriter, kind, id, action string) {
+    var found bool
+    if kind == "authentications" {
+        _, found = a.h.Authentication(id)
+    } else {
+        _, found = a.h.Transaction(id)
+    }
+    if !found {
+        writeJSON(w, http.StatusNotFound, kind+" "+id+" not found")
+        return
+    }
+
+    var ok bool
+    var status ovotest.Status
+    switch action {
+    case "approve":
+        ok, status = a.h.Approve(id), ovotest.StatusApproved
+    case "reject":
+        ok, status = a.h.Reject(id), ovotest.StatusRejected
+    default:
+        writeJSON(w, http.StatusNotFound, "unknown action "+action)
+        return
+    }
+    if !ok {
+        writeJSON(w, http.StatusConflict, id+" is not pending")
+        return
+    }
+    writeJSON(w, http.StatusOK, id+" "+string(status))
+}
+
+func statusFilter(r *http.Request) []ovotest.Status {
+    if s := r.URL.Query().Get("status"); s != "" {
+        return []ovotest.Status{ovotest.Status(s)}
+    }
+    return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(v)
+}
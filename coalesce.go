@@ -0,0 +1,90 @@
+package ovo
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+)
+
+var errFlightPanic = errors.New("ovo: coalesced request panicked")
+
+//SetRequestCoalescing : Share one OVO request between concurrent identical GET calls, enabled by New.
+//The shared request keeps the context values of the first caller but not its cancellation,
+//every caller stops waiting when its own context is done
+func (client *Client) SetRequestCoalescing(enabled bool) {
+    if !enabled {
+        client.flight = nil
+    } else if client.flight == nil {
+        client.flight = &flightGroup{}
+    }
+}
+
+//do : Run fn once for concurrent calls with the same key, every caller gets its own copy of the data
+func (g *flightGroup) do(ctx context.Context, key string, fn func() ([]byte, error)) ([]byte, error) {
+    g.mu.Lock()
+    if g.calls == nil {
+        g.calls = map[string]*flightCall{}
+    }
+    if c, ok := g.calls[key]; ok {
+        c.dups++
+        g.mu.Unlock()
+        return c.wait(ctx)
+    }
+
+    c := &flightCall{done: make(chan struct{}), err: errFlightPanic}
+    g.calls[key] = c
+    g.mu.Unlock()
+
+    go func() {
+        defer func() {
+            if p := recover(); p != nil {
+                c.data, c.err = nil, fmt.Errorf("%w: %v", errFlightPanic, p)
+            }
+            g.mu.Lock()
+            delete(g.calls, key)
+            g.mu.Unlock()
+            close(c.done)
+        }()
+        c.data, c.err = fn()
+    }()
+
+    return c.wait(ctx)
+}
+
+//wait : Result of the call, or the error of ctx when it is done first
+func (c *flightCall) wait(ctx context.Context) ([]byte, error) {
+    select {
+    case <-c.done:
+        return copyBytes(c.data), c.err
+    case <-ctx.Done():
+        return nil, wrapErr(ctx.Err(), ErrCategoryTransport)
+    }
+}
+
+//Deadline : Detached context has no deadline
+func (detachedContext) Deadline() (time.Time, bool) {
+    return time.Time{}, false
+}
+
+//Done : Detached context is never done
+func (detachedContext) Done() <-chan struct{} {
+    return nil
+}
+
+//Err : Detached context is never canceled
+func (detachedContext) Err() error {
+    return nil
+}
+
+//Value : Value of the parent context
+func (d detachedContext) Value(key interface{}) interface{} {
+    return d.parent.Value(key)
+}
+
+func copyBytes(b []byte) []byte {
+    if b == nil {
+        return nil
+    }
+    return append([]byte(nil), b...)
+}
@@ -0,0 +1,171 @@
+package ovo
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func waitFlight(g *flightGroup, dups int) bool {
+    deadline := time.Now().Add(time.Second)
+    for time.Now().Before(deadline) {
+        g.mu.Lock()
+        n := 0
+        for _, c := range g.calls {
+            n += c.dups
+        }
+        g.mu.Unlock()
+        if n == dups {
+            return true
+        }
+        time.Sleep(time.Millisecond)
+    }
+    return false
+}
+
+func waitFlightCalls(g *flightGroup, n int) bool {
+    deadline := time.Now().Add(time.Second)
+    for time.Now().Before(deadline) {
+        g.mu.Lock()
+        calls := len(g.calls)
+        g.mu.Unlock()
+        if calls == n {
+            return true
+        }
+        time.Sleep(time.Millisecond)
+    }
+    return false
+}
+
+func TestRequestCoalescing(t *testing.T) {
+    var calls int32
+    release := make(chan struct{})
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&calls, 1)
+        <-release
+        w.Write([]byte(testProfile))
+    }
+
+    var wg sync.WaitGroup
+    results := make([][]byte, 10)
+    for i := range results {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            results[i], _ = client.GetCustomerProfile("081208080808")
+        }(i)
+    }
+
+    if !waitFlight(client.flight, 9) {
+        t.Fatalf("Concurrent calls should wait for the first request")
+    }
+    close(release)
+    wg.Wait()
+
+    if calls != 1 {
+        t.Errorf("Concurrent identical calls should share one request, got %d", calls)
+    }
+    results[0][0] = 'x'
+    for _, r := range results[1:] {
+        if string(r) != testProfile {
+            t.Errorf("Every caller should get its own copy of the response")
+        }
+    }
+
+    client.GetCustomerProfile("081208080808")
+    if calls != 2 {
+        t.Errorf("Finished request should not be shared")
+    }
+}
+
+func TestRequestCoalescingPost(t *testing.T) {
+    var calls int32
+    arrived := make(chan struct{}, 2)
+    release := make(chan struct{})
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&calls, 1)
+        arrived <- struct{}{}
+        <-release
+        w.Write([]byte(`{"status": 201}`))
+    }
+
+    var wg sync.WaitGroup
+    for i := 0; i < 2; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            client.CustomerAuthentication(Params{"phone": "081208080808"})
+        }()
+    }
+
+    for i := 0; i < 2; i++ {
+        select {
+        case <-arrived:
+        case <-time.After(time.Second):
+            t.Fatalf("POST should not be coalesced")
+        }
+    }
+    close(release)
+    wg.Wait()
+
+    client.SetRequestCoalescing(false)
+    if client.flight != nil {
+        t.Errorf("Coalescing should be disabled")
+    }
+}
+
+type gateLimiter chan struct{}
+
+func (g gateLimiter) Wait(ctx context.Context) error {
+    <-g
+    return ctx.Err()
+}
+
+func TestRequestCoalescingCanceledCaller(t *testing.T) {
+    gate := make(gateLimiter)
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.SetRateLimiter(gate)
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(testProfile))
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    leader := make(chan error, 1)
+    go func() {
+        _, err := client.WithContext(ctx).GetCustomerProfile("081208080808")
+        leader <- err
+    }()
+    if !waitFlightCalls(client.flight, 1) {
+        t.Fatalf("First call should start the shared request")
+    }
+
+    follower := make(chan []byte, 1)
+    go func() {
+        data, _ := client.GetCustomerProfile("081208080808")
+        follower <- data
+    }()
+    if !waitFlight(client.flight, 1) {
+        t.Fatalf("Second call should wait for the first request")
+    }
+
+    cancel()
+    select {
+    case err := <-leader:
+        if !errors.Is(err, context.Canceled) {
+            t.Errorf("Canceled caller should get its context error, got %v", err)
+        }
+    case <-time.After(time.Second):
+        t.Fatalf("Canceled caller should stop waiting")
+    }
+
+    close(gate)
+    if data := <-follower; string(data) != testProfile {
+        t.Errorf("Shared request should not be canceled with the first caller, got %s", data)
+    }
+}
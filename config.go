@@ -0,0 +1,159 @@
+package ovo
+
+import (
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "net/http"
+    "net/url"
+    "os"
+    "time"
+)
+
+//NewFromConfig : Constructor for OVO Client validating cfg, empty base url, locale and timeout take the defaults of cfg.Environment
+func NewFromConfig(cfg Config) (*Client, error) {
+    cfg, err := cfg.resolve()
+    if err != nil {
+        return nil, err
+    }
+
+    c := New(cfg.BaseURL, cfg.APIKey, cfg.AppID, cfg.MerchantID)
+    c.Environment = cfg.Environment
+    c.SetLocale(cfg.LocaleID)
+    if cfg.Timeout > 0 {
+        c.SetHTTPClient(&http.Client{Timeout: cfg.Timeout})
+    }
+
+    return c, nil
+}
+
+//LoadConfig : Read Config from the json file at path, then override it with the non empty OVO_* environment variables.
+//Path may be empty to read the environment variables only
+func LoadConfig(path string) (Config, error) {
+    var cfg Config
+
+    if path != "" {
+        data, err := ioutil.ReadFile(path)
+        if err != nil {
+            return cfg, err
+        }
+        if err := json.Unmarshal(data, &cfg); err != nil {
+            return cfg, fmt.Errorf("ovo: config %s: %s", path, err)
+        }
+    }
+
+    env := map[string]*string{
+        EnvVarBaseURL:    &cfg.BaseURL,
+        EnvVarAPIKey:     &cfg.APIKey,
+        EnvVarAppID:      &cfg.AppID,
+        EnvVarMerchantID: &cfg.MerchantID,
+        EnvVarLocale:     &cfg.LocaleID,
+    }
+    for k, v := range env {
+        if s := os.Getenv(k); s != "" {
+            *v = s
+        }
+    }
+    if s := os.Getenv(EnvVarEnvironment); s != "" {
+        cfg.Environment = Environment(s)
+    }
+    for env, profile := range environmentProfiles {
+        if s := os.Getenv(profile.BaseURLVar); s != "" {
+            if cfg.BaseURLs == nil {
+                cfg.BaseURLs = map[Environment]string{}
+            }
+            cfg.BaseURLs[env] = s
+        }
+    }
+    if s := os.Getenv(EnvVarTimeout); s != "" {
+        d, err := time.ParseDuration(s)
+        if err != nil {
+            return cfg, &ConfigError{Field: "timeout", Reason: err.Error()}
+        }
+        cfg.Timeout = d
+    }
+
+    return cfg, nil
+}
+
+//UnmarshalJSON : Decode Config with timeout written as a duration string, e.g. "10s"
+func (cfg *Config) UnmarshalJSON(data []byte) error {
+    type config Config
+    aux := struct {
+        *config
+        Timeout string `json:"timeout"`
+    }{config: (*config)(cfg)}
+
+    if err := json.Unmarshal(data, &aux); err != nil {
+        return err
+    }
+    if aux.Timeout != "" {
+        d, err := time.ParseDuration(aux.Timeout)
+        if err != nil {
+            return &ConfigError{Field: "timeout", Reason: err.Error()}
+        }
+        cfg.Timeout = d
+    }
+    return nil
+}
+
+//Validate : Check cfg can build a Client, the *ConfigError of the first invalid field is available with errors.As
+func (cfg Config) Validate() error {
+    _, err := cfg.resolve()
+    return err
+}
+
+//resolve : Fill cfg with the defaults of its Environment then validate it
+func (cfg Config) resolve() (Config, error) {
+    locale := cfg.LocaleID
+    if _, ok := ErrMessage["ovo_invalid_config"][locale]; !ok {
+        locale = "en"
+    }
+    invalid := func(field, reason string) (Config, error) {
+        return cfg, causeErr("ovo_invalid_config", locale, &ConfigError{Field: field, Reason: reason})
+    }
+
+    if cfg.Environment != "" {
+        profile, ok := environmentProfiles[cfg.Environment]
+        if !ok {
+            return invalid("environment", "unknown environment "+string(cfg.Environment))
+        }
+        if cfg.BaseURL == "" {
+            cfg.BaseURL = cfg.BaseURLs[cfg.Environment]
+        }
+        if cfg.LocaleID == "" {
+            cfg.LocaleID = profile.LocaleID
+        }
+        if cfg.Timeout == 0 {
+            cfg.Timeout = profile.Timeout
+        }
+    }
+    if cfg.LocaleID == "" {
+        cfg.LocaleID = "en"
+    }
+
+    if cfg.BaseURL == "" {
+        return invalid("base_url", "must not be empty, set the base url given by OVO for the environment in base_urls")
+    }
+    u, err := url.Parse(cfg.BaseURL)
+    if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+        return invalid("base_url", "must be an absolute http(s) url")
+    }
+    if cfg.APIKey == "" {
+        return invalid("api_key", "must not be empty")
+    }
+    if cfg.AppID == "" {
+        return invalid("app_id", "must not be empty")
+    }
+    if cfg.MerchantID == "" {
+        return invalid("merchant_id", "must not be empty")
+    }
+    if _, ok := ErrMessage["ovo_invalid_config"][cfg.LocaleID]; !ok {
+        return invalid("locale", "unsupported locale "+cfg.LocaleID)
+    }
+    if cfg.Timeout < 0 {
+        return invalid("timeout", "must not be negative")
+    }
+
+    return cfg, nil
+}
@@ -0,0 +1,119 @@
+package ovo
+
+import (
+    "errors"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func TestNewFromConfig(t *testing.T) {
+    client, err := NewFromConfig(Config{Environment: EnvProduction, BaseURL: "https://ovo.test/loyalty-back", APIKey: "secret", AppID: "hypermart", MerchantID: "1"})
+    if err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    if client.BaseURL != "https://ovo.test/loyalty-back" || client.LocaleID != "en" || client.Environment != EnvProduction {
+        t.Errorf("Client should take the environment defaults")
+    }
+    if client.getHTTPClient().Timeout != 10*time.Second {
+        t.Errorf("Client should use the environment timeout")
+    }
+    if client.Hmac != signature("hypermart", client.Random, "secret") {
+        t.Errorf("Client should be signed")
+    }
+
+    client, err = NewFromConfig(Config{Environment: EnvProduction, BaseURL: "http://localhost:8080", APIKey: "secret", AppID: "hypermart", MerchantID: "1", LocaleID: "id", Timeout: 5 * time.Second})
+    if err != nil || client.LocaleID != "id" || client.getHTTPClient().Timeout != 5*time.Second {
+        t.Errorf("Config should override the environment defaults")
+    }
+
+    baseURLs := map[Environment]string{EnvSandbox: "https://sandbox.ovo.test", EnvProduction: "https://ovo.test"}
+    client, err = NewFromConfig(Config{Environment: EnvSandbox, BaseURLs: baseURLs, APIKey: "secret", AppID: "hypermart", MerchantID: "1"})
+    if err != nil || client.BaseURL != "https://sandbox.ovo.test" {
+        t.Errorf("Client should take the base url of the environment, got %v", err)
+    }
+    client, err = NewFromConfig(Config{Environment: EnvSandbox, BaseURL: "http://localhost:8080", BaseURLs: baseURLs, APIKey: "secret", AppID: "hypermart", MerchantID: "1"})
+    if err != nil || client.BaseURL != "http://localhost:8080" {
+        t.Errorf("Base url should override the one of the environment, got %v", err)
+    }
+}
+
+func TestNewFromConfigInvalid(t *testing.T) {
+    valid := Config{BaseURL: "https://ovo.test", APIKey: "secret", AppID: "hypermart", MerchantID: "1"}
+
+    cases := map[string]func(*Config){
+        "api_key":     func(c *Config) { c.APIKey = "" },
+        "app_id":      func(c *Config) { c.AppID = "" },
+        "merchant_id": func(c *Config) { c.MerchantID = "" },
+        "environment": func(c *Config) { c.Environment = "qa" },
+        "locale":      func(c *Config) { c.LocaleID = "fr" },
+        "timeout":     func(c *Config) { c.Timeout = -time.Second },
+        "base_url":    func(c *Config) { c.BaseURL = "ovo.test" },
+    }
+    for field, change := range cases {
+        cfg := valid
+        change(&cfg)
+
+        _, err := NewFromConfig(cfg)
+        var ce *ConfigError
+        if !errors.As(err, &ce) || ce.Field != field {
+            t.Errorf("%s should be invalid, got %v", field, err)
+        }
+        if GetErrCategory(err) != ErrCategoryValidation {
+            t.Errorf("Invalid config should be a validation error")
+        }
+    }
+
+    for _, env := range []Environment{EnvSandbox, EnvStaging, EnvProduction} {
+        err := (Config{Environment: env, BaseURLs: map[Environment]string{"other": "https://ovo.test"}, APIKey: "secret", AppID: "hypermart", MerchantID: "1"}).Validate()
+        var ce *ConfigError
+        if !errors.As(err, &ce) || ce.Field != "base_url" {
+            t.Errorf("%s without base url should be invalid, got %v", env, err)
+        }
+    }
+}
+
+func TestLoadConfig(t *testing.T) {
+    dir, err := ioutil.TempDir("", "ovo")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+
+    path := filepath.Join(dir, "ovo.json")
+    data := `{"environment": "sandbox", "base_url": "https://ovo.test", "base_urls": {"sandbox": "https://sandbox.ovo.test"}, "api_key": "file-key", "app_id": "hypermart", "merchant_id": "1", "timeout": "3s"}`
+    if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+        t.Fatal(err)
+    }
+
+    os.Setenv(EnvVarAPIKey, "env-key")
+    os.Setenv(EnvVarTimeout, "7s")
+    os.Setenv(EnvVarProductionBaseURL, "https://production.ovo.test")
+    defer os.Unsetenv(EnvVarAPIKey)
+    defer os.Unsetenv(EnvVarTimeout)
+    defer os.Unsetenv(EnvVarProductionBaseURL)
+
+    cfg, err := LoadConfig(path)
+    if err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    if cfg.Environment != EnvSandbox || cfg.BaseURL != "https://ovo.test" || cfg.AppID != "hypermart" {
+        t.Errorf("Config should be read from file")
+    }
+    if cfg.APIKey != "env-key" || cfg.Timeout != 7*time.Second {
+        t.Errorf("Environment variables should override the file")
+    }
+    if cfg.BaseURLs[EnvSandbox] != "https://sandbox.ovo.test" || cfg.BaseURLs[EnvProduction] != "https://production.ovo.test" {
+        t.Errorf("Base urls should be read from file and environment variables, got %v", cfg.BaseURLs)
+    }
+
+    os.Setenv(EnvVarTimeout, "soon")
+    if _, err := LoadConfig(""); err == nil {
+        t.Errorf("Invalid timeout should be an error")
+    }
+    if _, err := LoadConfig(filepath.Join(dir, "missing.json")); err == nil {
+        t.Errorf("Missing file should be an error")
+    }
+}
@@ -1,5 +1,10 @@
 package ovo
 
+import (
+    "net/http"
+    "time"
+)
+
 const (
     //NoErrCode : No Error code is set
     NoErrCode = 0
@@ -38,21 +43,131 @@ const (
 )
 
 const (
-    //PhoneValidRegex : Regex to check if phone is valid
-    PhoneValidRegex = "(0|\\+)([0-9]{5,16})"
+    //ErrCategoryTransport : OVO or storage could not be reached, or the response could not be read
+    ErrCategoryTransport ErrCategory = "transport"
+
+    //ErrCategoryAuth : Request or customer is not authenticated
+    ErrCategoryAuth ErrCategory = "auth"
+
+    //ErrCategoryValidation : Request input is invalid
+    ErrCategoryValidation ErrCategory = "validation"
+
+    //ErrCategoryConflict : Request conflicts with existing linkage or transaction
+    ErrCategoryConflict ErrCategory = "conflict"
+
+    //ErrCategoryBusiness : Request is refused by OVO or sdk business rules
+    ErrCategoryBusiness ErrCategory = "business"
+)
+
+const (
+    //RandomLayout : Time layout of the random header signed along with the app id
+    RandomLayout = "20060102150405"
+
+    //DefaultMaxSkew : Default accepted age of the random header when verifying requests
+    DefaultMaxSkew = 5 * time.Minute
+
+    //MaxNotificationBytes : Largest notification body accepted by Webhook
+    MaxNotificationBytes = 64 << 10
+
+    //DefaultBackfillBatch : Default number of rows read per query by BackfillPhoneLookup
+    DefaultBackfillBatch = 500
+
+    //DefaultBatchConcurrency : Default number of profile lookups in flight for GetCustomerProfiles
+    DefaultBatchConcurrency = 8
+
+    //DefaultSyncMaxAge : Default age of the synced profile before SyncProfiles reads it again
+    DefaultSyncMaxAge = 24 * time.Hour
+
+    //DefaultUnlinkCooldown : Default period a phone unlinked from a customer cannot be linked by another customer
+    DefaultUnlinkCooldown = 30 * 24 * time.Hour
+)
+
+const (
+    //EnvSandbox : OVO sandbox, for development against test accounts
+    EnvSandbox Environment = "sandbox"
+
+    //EnvStaging : OVO staging, production like data for merchant UAT
+    EnvStaging Environment = "staging"
+
+    //EnvProduction : OVO production
+    EnvProduction Environment = "production"
+)
+
+const (
+    //EnvVarEnvironment : Environment variable read by LoadConfig, one per Config field
+    EnvVarEnvironment = "OVO_ENV"
+    EnvVarBaseURL     = "OVO_BASE_URL"
+    EnvVarAPIKey      = "OVO_API_KEY"
+    EnvVarAppID       = "OVO_APP_ID"
+    EnvVarMerchantID  = "OVO_MERCHANT_ID"
+    EnvVarLocale      = "OVO_LOCALE"
+    EnvVarTimeout     = "OVO_TIMEOUT"
+)
+
+const (
+    //EnvVarSandboxBaseURL : Environment variable read by LoadConfig, base url of one Environment
+    EnvVarSandboxBaseURL    = "OVO_SANDBOX_BASE_URL"
+    EnvVarStagingBaseURL    = "OVO_STAGING_BASE_URL"
+    EnvVarProductionBaseURL = "OVO_PRODUCTION_BASE_URL"
+)
+
+const (
+    //DefaultPathParamPattern : Regex of path param values without pattern, unreserved url characters only
+    DefaultPathParamPattern = "^[A-Za-z0-9._~-]{1,128}$"
+)
+
+const (
+    //EndpointCustomerProfile : Get customer profile by loyalty id or phone
+    EndpointCustomerProfile = "customer_profile"
+    //EndpointCalculatePoints : Calculate and earn points of a purchase
+    EndpointCalculatePoints = "calculate_points"
+    //EndpointPushToPayTransaction : Create push to pay transaction
+    EndpointPushToPayTransaction = "pushtopay_transaction"
+    //EndpointPushToPayTransactionStatus : Get push to pay transaction status
+    EndpointPushToPayTransactionStatus = "pushtopay_transaction_status"
+    //EndpointPushToPayVoidTransaction : Void push to pay transaction
+    EndpointPushToPayVoidTransaction = "pushtopay_void_transaction"
+    //EndpointCustomerProfileQR : Get profile of the customer scanned by the terminal
+    EndpointCustomerProfileQR = "customer_profile_qr"
+    //EndpointCustomerLinkage : Link customer to the merchant
+    EndpointCustomerLinkage = "customer_linkage"
+    //EndpointCustomerAuthentication : Push security code screen to customer device
+    EndpointCustomerAuthentication = "customer_authentication"
+    //EndpointCustomerAuthenticationStatus : Get customer authentication status
+    EndpointCustomerAuthenticationStatus = "customer_authentication_status"
+)
+
+var (
+    //environmentProfiles : Client defaults of each Environment. OVO hands out the base url of each environment
+    //when onboarding the merchant, it is read from Config.BaseURLs or the environment variable of the profile
+    environmentProfiles = map[Environment]environmentProfile{
+        EnvSandbox:    {BaseURLVar: EnvVarSandboxBaseURL, Timeout: 30 * time.Second, LocaleID: "en"},
+        EnvStaging:    {BaseURLVar: EnvVarStagingBaseURL, Timeout: 30 * time.Second, LocaleID: "en"},
+        EnvProduction: {BaseURLVar: EnvVarProductionBaseURL, Timeout: 10 * time.Second, LocaleID: "en"},
+    }
 )
 
 var (
-    domainMap = map[string]string{
-        "customer_profile":               "/customers/:customer_id",                                                   //GET
-        "calculate_points":               "/customers/:customer_id/points",                                            //PUT
-        "pushtopay_transaction":          "/customers/:customer_id/transactions",                                      //POST
-        "pushtopay_transaction_status":   "/customers/:customer_id/transactions/:transaction_id",                      //GET
-        "pushtopay_void_transaction":     "/customers/:customer_id/transactions/:transaction_id",                      //PUT
-        "customer_profile_qr":            "/merchants/:merchant_id/stores/:store_id/terminals/:terminal_id/customers", //GET
-        "customer_linkage":               "/customers/:customer_id",                                                   //POST
-        "customer_authentication":        "/authentications",                                                          // POST
-        "customer_authentication_status": "/authentications/:authentication_id",                                       //GET
+    //PathParamPatterns : Regex of the OVO path params by name, used by endpoints without their own pattern
+    PathParamPatterns = map[string]string{
+        "customer_id":       "^\\+?[0-9]{1,20}$",
+        "transaction_id":    "^[A-Za-z0-9_-]{1,64}$",
+        "authentication_id": "^[A-Za-z0-9_-]{1,64}$",
+        "merchant_id":       "^[A-Za-z0-9_-]{1,64}$",
+        "store_id":          "^[A-Za-z0-9_-]{1,64}$",
+        "terminal_id":       "^[A-Za-z0-9_-]{1,64}$",
+    }
+
+    defaultEndpoints = []Endpoint{
+        {Name: EndpointCustomerProfile, Method: "GET", Path: "/customers/:customer_id", SuccessStatus: http.StatusOK},
+        {Name: EndpointCalculatePoints, Method: "PUT", Path: "/customers/:customer_id/points", SuccessStatus: http.StatusOK},
+        {Name: EndpointPushToPayTransaction, Method: "POST", Path: "/customers/:customer_id/transactions", SuccessStatus: http.StatusCreated},
+        {Name: EndpointPushToPayTransactionStatus, Method: "GET", Path: "/customers/:customer_id/transactions/:transaction_id", SuccessStatus: http.StatusOK},
+        {Name: EndpointPushToPayVoidTransaction, Method: "PUT", Path: "/customers/:customer_id/transactions/:transaction_id", SuccessStatus: http.StatusOK},
+        {Name: EndpointCustomerProfileQR, Method: "GET", Path: "/merchants/:merchant_id/stores/:store_id/terminals/:terminal_id/customers", SuccessStatus: http.StatusOK},
+        {Name: EndpointCustomerLinkage, Method: "POST", Path: "/customers/:customer_id", SuccessStatus: http.StatusCreated},
+        {Name: EndpointCustomerAuthentication, Method: "POST", Path: "/authentications", SuccessStatus: http.StatusCreated},
+        {Name: EndpointCustomerAuthenticationStatus, Method: "GET", Path: "/authentications/:authentication_id", SuccessStatus: http.StatusOK},
     }
 )
 
@@ -111,5 +226,167 @@ var (
             "id": "Maaf, Anda belum terotentifikasi",
             "en": "Sorry, You are not yet authenticated",
         },
+        "ovo_invalid_signature": {
+            "id": "Tanda tangan permintaan OVO tidak valid",
+            "en": "Invalid OVO request signature",
+        },
+        "ovo_expired_signature": {
+            "id": "Tanda tangan permintaan OVO sudah kedaluwarsa",
+            "en": "OVO request signature has expired",
+        },
+        "ovo_not_linked": {
+            "id": "Akun OVO belum terhubung",
+            "en": "OVO account is not linked",
+        },
+        "ovo_invalid_unlink_reason": {
+            "id": "Alasan pemutusan akun OVO tidak valid",
+            "en": "Invalid OVO unlink reason",
+        },
+        "ovo_phone_cooldown": {
+            "id": "Nomor telepon ini baru saja dilepas dari akun lain, silahkan coba lagi nanti",
+            "en": "This phone number was recently unlinked from another account, please try again later",
+        },
+        "ovo_phone_cipher": {
+            "id": "Gagal mengenkripsi nomor telepon OVO",
+            "en": "Unable to encrypt OVO phone number",
+        },
+        "ovo_invalid_path_param": {
+            "id": "Parameter permintaan layanan OVO tidak valid",
+            "en": "Invalid OVO service request parameter",
+        },
+        "ovo_invalid_config": {
+            "id": "Konfigurasi OVO tidak valid",
+            "en": "Invalid OVO configuration",
+        },
+        "ovo_unknown_merchant": {
+            "id": "Merchant OVO tidak dikenal",
+            "en": "Unknown OVO merchant",
+        },
+        "ovo_no_credentials": {
+            "id": "Kredensial OVO tidak tersedia",
+            "en": "OVO credentials are not available",
+        },
+        "ovo_replayed_request": {
+            "id": "Permintaan OVO sudah pernah diterima",
+            "en": "OVO request has already been received",
+        },
+        "ovo_callback_unconfirmed": {
+            "id": "Notifikasi OVO tidak sesuai dengan status di OVO",
+            "en": "OVO notification does not match the status at OVO",
+        },
+    }
+)
+
+var (
+    //errKinds : Category and temporary flag of each ErrMessage keyword
+    errKinds = map[string]errKind{
+        "ovo_unavailable_service":   {ErrCategoryTransport, true},
+        "ovo_invalid_response":      {ErrCategoryTransport, false},
+        "ovo_unregistered_customer": {ErrCategoryBusiness, false},
+        "ovo_phone_empty":           {ErrCategoryValidation, false},
+        "ovo_id_invalid":            {ErrCategoryValidation, false},
+        "ovo_retry_verification":    {ErrCategoryAuth, true},
+        "ovo_unidentified_request":  {ErrCategoryValidation, false},
+        "ovo_customer_unidentified": {ErrCategoryBusiness, false},
+        "ovo_already_verified":      {ErrCategoryConflict, false},
+        "ovo_change_verified":       {ErrCategoryConflict, false},
+        "ovo_id_used":               {ErrCategoryConflict, false},
+        "ovo_unknown_info":          {ErrCategoryBusiness, false},
+        "ovo_not_authenticated":     {ErrCategoryAuth, false},
+        "ovo_invalid_signature":     {ErrCategoryAuth, false},
+        "ovo_expired_signature":     {ErrCategoryAuth, false},
+        "ovo_replayed_request":      {ErrCategoryConflict, false},
+        "ovo_not_linked":            {ErrCategoryBusiness, false},
+        "ovo_invalid_unlink_reason": {ErrCategoryValidation, false},
+        "ovo_phone_cooldown":        {ErrCategoryConflict, false},
+        "ovo_phone_cipher":          {ErrCategoryTransport, false},
+        "ovo_invalid_path_param":    {ErrCategoryValidation, false},
+        "ovo_invalid_config":        {ErrCategoryValidation, false},
+        "ovo_unknown_merchant":      {ErrCategoryValidation, false},
+        "ovo_no_credentials":        {ErrCategoryAuth, false},
+        "ovo_callback_unconfirmed":  {ErrCategoryBusiness, true},
+    }
+)
+
+const (
+    //EventTransactionSuccess : Push to pay transaction paid by the customer
+    EventTransactionSuccess EventType = "transaction_success"
+
+    //EventTransactionFailed : Push to pay transaction rejected, expired or failed
+    EventTransactionFailed EventType = "transaction_failed"
+
+    //EventAuthenticationApproved : Customer entered the security code
+    EventAuthenticationApproved EventType = "authentication_approved"
+
+    //EventAuthenticationRejected : Customer rejected or did not finish the authentication
+    EventAuthenticationRejected EventType = "authentication_rejected"
+)
+
+const (
+    //UnlinkAccountClosed : Customer closed the OVO account
+    UnlinkAccountClosed UnlinkReason = "account_closed"
+
+    //UnlinkPhoneLost : Customer lost the phone number used as OVO ID
+    UnlinkPhoneLost UnlinkReason = "phone_lost"
+
+    //UnlinkPhoneChanged : Customer links another OVO ID
+    UnlinkPhoneChanged UnlinkReason = "phone_changed"
+
+    //UnlinkCustomerRequest : Customer asked to remove the linkage
+    UnlinkCustomerRequest UnlinkReason = "customer_request"
+
+    //UnlinkFraud : Linkage removed by customer service after a dispute or fraud report
+    UnlinkFraud UnlinkReason = "fraud"
+)
+
+var (
+    unlinkReasons = map[UnlinkReason]bool{
+        UnlinkAccountClosed:   true,
+        UnlinkPhoneLost:       true,
+        UnlinkPhoneChanged:    true,
+        UnlinkCustomerRequest: true,
+        UnlinkFraud:           true,
     }
 )
+
+const (
+    //HistoryInsert : Linkage created
+    HistoryInsert HistoryAction = "insert"
+
+    //HistoryUpdate : Linkage phone, authentication or verification changed
+    HistoryUpdate HistoryAction = "update"
+
+    //HistoryUnlink : Linkage removed
+    HistoryUnlink HistoryAction = "unlink"
+
+    //ActorOVO : Change made from an OVO callback
+    ActorOVO = "ovo"
+
+    //ReasonAuthenticationSent : Authentication pushed to the customer phone
+    ReasonAuthenticationSent = "authentication_sent"
+
+    //ReasonStatusCheck : Authentication status polled from OVO
+    ReasonStatusCheck = "status_check"
+
+    //ReasonAuthenticationCallback : Authentication result received from OVO callback
+    ReasonAuthenticationCallback = "authentication_callback"
+)
+
+const (
+    //FunnelAuthSent : Authentication pushed to the customer phone
+    FunnelAuthSent FunnelStep = "auth_sent"
+
+    //FunnelVerified : Linkage verified
+    FunnelVerified FunnelStep = "verified"
+
+    //FunnelRejected : Authentication rejected by the customer, or authentication or customer not found by OVO
+    FunnelRejected FunnelStep = "rejected"
+
+    //FunnelIDUsed : Phone already linked to another customer
+    FunnelIDUsed FunnelStep = "id_used"
+)
+
+var (
+    //DefaultLatencyBuckets : Default upper bounds, in seconds, of the OVO call latency histogram
+    DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+)
@@ -0,0 +1,163 @@
+package ovo
+
+import (
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "time"
+)
+
+//Credentials : Call the function
+func (f CredentialProviderFunc) Credentials() (Credentials, error) {
+    return f()
+}
+
+//SigningKey : Api key signing requests sent at now, the previous key until RotateAt
+func (c Credentials) SigningKey(now time.Time) string {
+    if c.PreviousAPIKey != "" && now.Before(c.RotateAt) {
+        return c.PreviousAPIKey
+    }
+    return c.APIKey
+}
+
+//Keys : Api keys accepted while rotating, current key first
+func (c Credentials) Keys() []string {
+    keys := []string{c.APIKey}
+    if c.PreviousAPIKey != "" {
+        keys = append(keys, c.PreviousAPIKey)
+    }
+    return keys
+}
+
+//SetCredentialProvider : Sign every request with the credentials of p instead of AppID and APIKey.
+//An empty app id from p keeps AppID
+func (client *Client) SetCredentialProvider(p CredentialProvider) {
+    client.credentials = p
+}
+
+//signingKey : App id and api key signing a request sent at now
+func (client *Client) signingKey(now time.Time) (string, string, error) {
+    if client.credentials == nil {
+        return client.AppID, client.APIKey, nil
+    }
+
+    creds, err := client.credentials.Credentials()
+    if err == nil && creds.APIKey == "" {
+        err = fmt.Errorf("ovo: credentials without api key")
+    }
+    if err != nil {
+        return "", "", causeErr("ovo_no_credentials", client.LocaleID, err)
+    }
+
+    appID := creds.AppID
+    if appID == "" {
+        appID = client.AppID
+    }
+    return appID, creds.SigningKey(now), nil
+}
+
+//CredentialKeys : KeyResolver accepting the current and previous api key of p for its app id
+func CredentialKeys(p CredentialProvider) KeyResolver {
+    return KeyResolverFunc(func(appID string) ([]string, error) {
+        creds, err := p.Credentials()
+        if err != nil {
+            return nil, err
+        }
+        if creds.AppID != appID {
+            return nil, nil
+        }
+        return creds.Keys(), nil
+    })
+}
+
+//NewFileCredentialProvider : Constructor for FileCredentialProvider, the file at path is checked for changes every interval.
+//Close stops watching the file
+func NewFileCredentialProvider(path string, interval time.Duration) (*FileCredentialProvider, error) {
+    p := &FileCredentialProvider{path: path, done: make(chan struct{})}
+    if err := p.Reload(); err != nil {
+        return nil, err
+    }
+
+    if interval > 0 {
+        go p.watch(interval)
+    }
+    return p, nil
+}
+
+//Credentials : Credentials last read from the file
+func (p *FileCredentialProvider) Credentials() (Credentials, error) {
+    p.mu.RLock()
+    defer p.mu.RUnlock()
+
+    return p.creds, nil
+}
+
+//Err : Error of the last reload, the previous credentials are kept when reading the file fails
+func (p *FileCredentialProvider) Err() error {
+    p.mu.RLock()
+    defer p.mu.RUnlock()
+
+    return p.err
+}
+
+//Reload : Read the file again if it changed since the last read
+func (p *FileCredentialProvider) Reload() error {
+    info, err := os.Stat(p.path)
+    if err != nil {
+        return p.setErr(err)
+    }
+
+    p.mu.RLock()
+    unchanged := info.ModTime().Equal(p.modTime) && p.creds.APIKey != ""
+    p.mu.RUnlock()
+    if unchanged {
+        return nil
+    }
+
+    data, err := ioutil.ReadFile(p.path)
+    if err != nil {
+        return p.setErr(err)
+    }
+
+    var creds Credentials
+    if err := json.Unmarshal(data, &creds); err != nil {
+        return p.setErr(fmt.Errorf("ovo: credentials %s: %s", p.path, err))
+    }
+    if creds.APIKey == "" {
+        return p.setErr(fmt.Errorf("ovo: credentials %s without api key", p.path))
+    }
+
+    p.mu.Lock()
+    p.creds = creds
+    p.modTime = info.ModTime()
+    p.err = nil
+    p.mu.Unlock()
+    return nil
+}
+
+//Close : Stop watching the file
+func (p *FileCredentialProvider) Close() {
+    p.once.Do(func() { close(p.done) })
+}
+
+func (p *FileCredentialProvider) setErr(err error) error {
+    p.mu.Lock()
+    p.err = err
+    p.mu.Unlock()
+    return err
+}
+
+func (p *FileCredentialProvider) watch(interval time.Duration) {
+    t := time.NewTicker(interval)
+    defer t.Stop()
+
+    for {
+        select {
+        case <-t.C:
+            p.Reload()
+        case <-p.done:
+            return
+        }
+    }
+}
@@ -0,0 +1,114 @@
+package ovo
+
+import (
+    "errors"
+    "io/ioutil"
+    "net/http"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func TestCredentialProvider(t *testing.T) {
+    creds := Credentials{AppID: "hypermart", APIKey: "new-key", PreviousAPIKey: "old-key", RotateAt: time.Now().Add(time.Hour)}
+    provider := CredentialProviderFunc(func() (Credentials, error) { return creds, nil })
+
+    var header http.Header
+    client := New("http://testing.com", "stale-key", "hypermart", "1")
+    client.SetCredentialProvider(provider)
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        header = r.Header
+        if err := VerifyRequest(r, CredentialKeys(provider)); err != nil {
+            t.Errorf("Request should be accepted while rotating, got %s", err)
+        }
+        w.Write([]byte(`{"status": 200}`))
+    }
+
+    client.GetCustomerProfile("081234567890")
+    if header.Get("hmac") != signature("hypermart", header.Get("random"), "old-key") {
+        t.Errorf("Previous key should sign until RotateAt")
+    }
+
+    creds.RotateAt = time.Now().Add(-time.Minute)
+    client.GetCustomerProfile("081234567890")
+    if header.Get("hmac") != signature("hypermart", header.Get("random"), "new-key") {
+        t.Errorf("New key should sign after RotateAt")
+    }
+
+    creds.PreviousAPIKey = ""
+    if keys, _ := CredentialKeys(provider).ResolveKeys("hypermart"); len(keys) != 1 || keys[0] != "new-key" {
+        t.Errorf("Only the new key should be accepted after rotation, got %v", keys)
+    }
+    if keys, _ := CredentialKeys(provider).ResolveKeys("foodmart"); len(keys) != 0 {
+        t.Errorf("Keys of another app id should not be accepted")
+    }
+}
+
+func TestCredentialProviderError(t *testing.T) {
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.SetCredentialProvider(CredentialProviderFunc(func() (Credentials, error) {
+        return Credentials{}, errors.New("vault sealed")
+    }))
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        t.Errorf("Request should not be sent unsigned")
+    }
+
+    _, err := client.GetCustomerProfile("081234567890")
+    if err == nil || err.Error() != TErr("ovo_no_credentials", "en").Error() {
+        t.Errorf("Provider error should be returned, got %v", err)
+    }
+}
+
+func TestFileCredentialProvider(t *testing.T) {
+    dir, err := ioutil.TempDir("", "ovo")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+
+    path := filepath.Join(dir, "credentials.json")
+    write := func(data string, mod time.Time) {
+        if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+            t.Fatal(err)
+        }
+        os.Chtimes(path, mod, mod)
+    }
+
+    start := time.Now().Add(-time.Hour)
+    write(`{"app_id": "hypermart", "api_key": "old-key"}`, start)
+
+    p, err := NewFileCredentialProvider(path, 10*time.Millisecond)
+    if err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    defer p.Close()
+
+    if c, _ := p.Credentials(); c.APIKey != "old-key" {
+        t.Errorf("Credentials should be read from file")
+    }
+
+    write(`{"app_id": "hypermart", "api_key": "new-key", "previous_api_key": "old-key"}`, start.Add(time.Minute))
+    deadline := time.Now().Add(time.Second)
+    for time.Now().Before(deadline) {
+        if c, _ := p.Credentials(); c.APIKey == "new-key" {
+            break
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+    if c, _ := p.Credentials(); c.APIKey != "new-key" || c.PreviousAPIKey != "old-key" {
+        t.Errorf("Changed file should be picked up")
+    }
+
+    write(`{"app_id": "hypermart"`, start.Add(2*time.Minute))
+    if err := p.Reload(); err == nil || p.Err() == nil {
+        t.Errorf("Invalid file should be an error")
+    }
+    if c, _ := p.Credentials(); c.APIKey != "new-key" {
+        t.Errorf("Last good credentials should be kept")
+    }
+
+    if _, err := NewFileCredentialProvider(filepath.Join(dir, "missing.json"), 0); err == nil {
+        t.Errorf("Missing file should be an error")
+    }
+}
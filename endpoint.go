@@ -0,0 +1,183 @@
+package ovo
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "net/url"
+    "regexp"
+    "sort"
+    "strings"
+    "sync"
+)
+
+var (
+    pathParamRegex = regexp.MustCompile(":[a-zA-Z0-9_]+")
+
+    endpointsMu sync.RWMutex
+    endpoints   = map[string]Endpoint{}
+)
+
+func init() {
+    for _, e := range defaultEndpoints {
+        if err := RegisterEndpoint(e); err != nil {
+            panic(err)
+        }
+    }
+}
+
+//RegisterEndpoint : Add an OVO endpoint callable with Client.Call, names already registered are rejected
+func RegisterEndpoint(e Endpoint) error {
+    if e.Name == "" {
+        return errors.New("ovo: endpoint name must not be empty")
+    }
+    switch e.Method {
+    case "GET", "POST", "PUT", "PATCH", "DELETE":
+    default:
+        return fmt.Errorf("ovo: endpoint %s has unsupported method %q", e.Name, e.Method)
+    }
+    if !strings.HasPrefix(e.Path, "/") {
+        return fmt.Errorf("ovo: endpoint %s path must start with /", e.Name)
+    }
+    if e.SuccessStatus == 0 {
+        e.SuccessStatus = http.StatusOK
+    }
+
+    e.params = nil
+    e.patterns = map[string]*regexp.Regexp{}
+    for _, v := range pathParamRegex.FindAllString(e.Path, -1) {
+        name := v[1:]
+        pattern, ok := e.ParamPatterns[name]
+        if !ok {
+            pattern, ok = PathParamPatterns[name]
+        }
+        if !ok {
+            pattern = DefaultPathParamPattern
+        }
+
+        re, err := regexp.Compile(pattern)
+        if err != nil {
+            return fmt.Errorf("ovo: endpoint %s param %s pattern: %s", e.Name, name, err)
+        }
+        e.params = append(e.params, name)
+        e.patterns[name] = re
+    }
+    for name := range e.ParamPatterns {
+        if e.patterns[name] == nil {
+            return fmt.Errorf("ovo: endpoint %s has no path param %s", e.Name, name)
+        }
+    }
+
+    endpointsMu.Lock()
+    defer endpointsMu.Unlock()
+
+    if _, ok := endpoints[e.Name]; ok {
+        return fmt.Errorf("ovo: endpoint %s already registered", e.Name)
+    }
+    endpoints[e.Name] = e
+    return nil
+}
+
+//LookupEndpoint : Get registered endpoint by name
+func LookupEndpoint(name string) (Endpoint, bool) {
+    endpointsMu.RLock()
+    defer endpointsMu.RUnlock()
+
+    e, ok := endpoints[name]
+    return e, ok
+}
+
+//Endpoints : Registered endpoints sorted by name
+func Endpoints() []Endpoint {
+    endpointsMu.RLock()
+    list := make([]Endpoint, 0, len(endpoints))
+    for _, e := range endpoints {
+        list = append(list, e)
+    }
+    endpointsMu.RUnlock()
+
+    sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+    return list
+}
+
+//Routes : Copy of the OVO endpoint paths keyed by endpoint name
+func Routes() map[string]string {
+    routes := map[string]string{}
+    for _, e := range Endpoints() {
+        routes[e.Name] = e.Path
+    }
+    return routes
+}
+
+//PathParams : Names of the path params, in order of appearance
+func (e Endpoint) PathParams() []string {
+    return append([]string(nil), e.params...)
+}
+
+//URL : Path with every param replaced by its escaped value, returns *PathParamError when a param is missing, unknown
+//or does not match its pattern
+func (e Endpoint) URL(params Params) (string, error) {
+    for name, v := range params {
+        if e.patterns[name] == nil {
+            return "", &PathParamError{Endpoint: e.Name, Param: name, Value: v}
+        }
+    }
+    for _, name := range e.params {
+        if !e.patterns[name].MatchString(params[name]) {
+            return "", &PathParamError{Endpoint: e.Name, Param: name, Value: params[name]}
+        }
+    }
+
+    path := pathParamRegex.ReplaceAllStringFunc(e.Path, func(v string) string {
+        return url.PathEscape(params[v[1:]])
+    })
+    return path, nil
+}
+
+//EndpointURL : Full url of the registered endpoint name with named path params, the *PathParamError of an invalid param
+//is available with errors.As
+func (client *Client) EndpointURL(name string, params Params) (string, error) {
+    e, ok := LookupEndpoint(name)
+    if !ok {
+        return "", TErr("ovo_unidentified_request", client.LocaleID)
+    }
+
+    path, err := e.URL(params)
+    if err != nil {
+        return "", causeErr("ovo_invalid_path_param", client.LocaleID, err)
+    }
+    return client.BaseURL + path, nil
+}
+
+//getURL : Full url of the registered endpoint name with path params given in order of appearance
+func (client *Client) getURL(name string, params ...string) (string, error) {
+    e, ok := LookupEndpoint(name)
+    if !ok || len(params) != len(e.params) {
+        return "", TErr("ovo_unidentified_request", client.LocaleID)
+    }
+
+    named := Params{}
+    for i, v := range params {
+        named[e.params[i]] = v
+    }
+    return client.EndpointURL(name, named)
+}
+
+//Call : Send params as form to the registered endpoint name, pathParams fill the path.
+//Use it for endpoints added with RegisterEndpoint
+func (client *Client) Call(name string, pathParams, params Params) ([]byte, error) {
+    url, err := client.EndpointURL(name, pathParams)
+    if err != nil {
+        return nil, err
+    }
+
+    e, _ := LookupEndpoint(name)
+    if e.Method == "GET" || e.Method == "DELETE" {
+        return client.execRequest(name, url, nil)
+    }
+
+    if params == nil {
+        params = Params{}
+    }
+    return client.execRequest(name, url, client.createParams(params))
+}
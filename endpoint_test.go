@@ -0,0 +1,166 @@
+package ovo
+
+import (
+    "errors"
+    "net/http"
+    "testing"
+)
+
+func TestEndpointRegistry(t *testing.T) {
+    for name, path := range TestDomainMap {
+        e, ok := LookupEndpoint(name)
+        if !ok || e.Path != path {
+            t.Errorf("%s should be registered", name)
+        }
+    }
+
+    e, _ := LookupEndpoint(EndpointPushToPayVoidTransaction)
+    if e.Method != "PUT" || e.SuccessStatus != http.StatusOK {
+        t.Errorf("Void transaction should be PUT expecting 200")
+    }
+    params := e.PathParams()
+    if len(params) != 2 || params[0] != "customer_id" || params[1] != "transaction_id" {
+        t.Errorf("Path params should be in order of appearance, got %v", params)
+    }
+
+    if err := RegisterEndpoint(Endpoint{Name: EndpointCustomerProfile, Method: "GET", Path: "/customers/:id"}); err == nil {
+        t.Errorf("Registered name should not be replaced")
+    }
+    if err := RegisterEndpoint(Endpoint{Name: "test_invalid", Method: "FETCH", Path: "/x"}); err == nil {
+        t.Errorf("Unsupported method should be rejected")
+    }
+    if err := RegisterEndpoint(Endpoint{Name: "test_invalid", Method: "GET", Path: "x"}); err == nil {
+        t.Errorf("Relative path should be rejected")
+    }
+}
+
+func TestEndpointURL(t *testing.T) {
+    client := New("http://testing.com", "", "", "")
+
+    url, err := client.EndpointURL(EndpointPushToPayTransactionStatus, Params{"transaction_id": "TRX-1", "customer_id": "8000"})
+    if err != nil || url != "http://testing.com/customers/8000/transactions/TRX-1" {
+        t.Errorf("Named params should be set into the path, got %s", url)
+    }
+
+    registerTestEndpoint(t, Endpoint{Name: "test_search", Method: "GET", Path: "/search/:q", ParamPatterns: map[string]string{"q": "^.+$"}})
+    url, err = client.EndpointURL("test_search", Params{"q": "a/b c?d"})
+    if err != nil || url != "http://testing.com/search/a%2Fb%20c%3Fd" {
+        t.Errorf("Param should be escaped, got %s", url)
+    }
+
+    if _, err := client.EndpointURL("unknown_endpoint", nil); err == nil {
+        t.Errorf("Unknown endpoint should be an error")
+    }
+}
+
+func TestEndpointURLInvalidParam(t *testing.T) {
+    client := New("http://testing.com", "", "", "")
+
+    cases := []struct {
+        endpoint string
+        params   Params
+        param    string
+    }{
+        {EndpointCustomerProfile, Params{"customer_id": "8000/transactions"}, "customer_id"},
+        {EndpointCustomerProfile, Params{"customer_id": "8000?x=1"}, "customer_id"},
+        {EndpointCustomerProfile, Params{"customer_id": ""}, "customer_id"},
+        {EndpointCustomerAuthenticationStatus, Params{"authentication_id": "../customers/1"}, "authentication_id"},
+        {EndpointPushToPayTransactionStatus, Params{"customer_id": "8000", "id": "1"}, "id"},
+        {EndpointCustomerProfileQR, Params{"merchant_id": "1", "store_id": "2", "terminal_id": "3#"}, "terminal_id"},
+    }
+
+    for _, c := range cases {
+        _, err := client.EndpointURL(c.endpoint, c.params)
+        var pe *PathParamError
+        if !errors.As(err, &pe) || pe.Param != c.param || pe.Endpoint != c.endpoint {
+            t.Errorf("%s should be reported as invalid param of %s, got %v", c.param, c.endpoint, err)
+        }
+        if GetErrCategory(err) != ErrCategoryValidation {
+            t.Errorf("Invalid param should be a validation error")
+        }
+    }
+
+    if _, err := client.getURL(EndpointCustomerProfile, "8000/points"); err == nil {
+        t.Errorf("Positional param should be validated")
+    }
+    if _, err := client.GetCustomerProfile("8000?customer_id=1"); err == nil {
+        t.Errorf("Request with invalid param should not be sent")
+    }
+    if err := RegisterEndpoint(Endpoint{Name: "test_invalid", Method: "GET", Path: "/x/:id", ParamPatterns: map[string]string{"other": ".*"}}); err == nil {
+        t.Errorf("Pattern of unknown param should be rejected")
+    }
+}
+
+//registerTestEndpoint : Register the endpoint until the test ends, so tests can run repeatedly
+func registerTestEndpoint(t *testing.T, e Endpoint) {
+    if err := RegisterEndpoint(e); err != nil {
+        t.Fatalf("Endpoint should be registered, got %s", err)
+    }
+    t.Cleanup(func() {
+        endpointsMu.Lock()
+        delete(endpoints, e.Name)
+        endpointsMu.Unlock()
+    })
+}
+
+func TestCallRegisteredEndpoint(t *testing.T) {
+    registerTestEndpoint(t, Endpoint{Name: "test_customer_vouchers", Method: "POST", Path: "/customers/:customer_id/vouchers", SuccessStatus: http.StatusCreated})
+
+    var method, path, form string
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        method, path = r.Method, r.URL.Path
+        r.ParseForm()
+        form = r.PostForm.Get("code")
+        w.WriteHeader(http.StatusCreated)
+        w.Write([]byte(`{"status": 201}`))
+    }
+
+    if _, err := client.Call("test_customer_vouchers", Params{"customer_id": "8000"}, Params{"code": "HYPER"}); err != nil {
+        t.Fatalf("Registered endpoint should be callable, got %s", err)
+    }
+    if method != "POST" || path != "/customers/8000/vouchers" || form != "HYPER" {
+        t.Errorf("Request should follow the registered endpoint, got %s %s %s", method, path, form)
+    }
+    if _, ok := Routes()["test_customer_vouchers"]; !ok {
+        t.Errorf("Routes should list registered endpoints")
+    }
+}
+
+func TestCallUnexpectedStatus(t *testing.T) {
+    registerTestEndpoint(t, Endpoint{Name: "test_customer_vouchers", Method: "POST", Path: "/customers/:customer_id/vouchers", SuccessStatus: http.StatusCreated})
+
+    var response string
+    status := http.StatusConflict
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(status)
+        w.Write([]byte(response))
+    }
+
+    response = `{"status": 409, "message": "Voucher already used", "code": 9}`
+    _, err := client.Call("test_customer_vouchers", Params{"customer_id": "8000"}, nil)
+    if GetErrCategory(err) != ErrCategoryConflict || GetErrCode(err) != 9 {
+        t.Errorf("Unexpected status should be classified by the OVO response, got %v", err)
+    }
+
+    response = `<html>Conflict</html>`
+    _, err = client.Call("test_customer_vouchers", Params{"customer_id": "8000"}, nil)
+    if err == nil || err.Error() != TErr("ovo_invalid_response", client.LocaleID).Error() || IsRetryable(err) {
+        t.Errorf("Unexpected status without OVO response should be invalid response, got %v", err)
+    }
+
+    status = http.StatusNotFound
+    response = `<html>Not Found</html>`
+    _, err = client.GetCustomerProfile("8000")
+    if err == nil || IsTemporary(err) || IsRetryable(err) {
+        t.Errorf("Not found without OVO response should not be retried, got %v", err)
+    }
+
+    status = http.StatusTooManyRequests
+    response = `<html>Too Many Requests</html>`
+    _, err = client.Call("test_customer_vouchers", Params{"customer_id": "8000"}, nil)
+    if err == nil || !IsTemporary(err) || !IsRetryable(err) {
+        t.Errorf("Throttled request without OVO response should be retried, got %v", err)
+    }
+}
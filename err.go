@@ -1,14 +1,48 @@
 package ovo
 
 import (
+    "context"
+    "database/sql/driver"
     "errors"
     "fmt"
+    "net/http"
 )
 
 func (e *CustomError) Error() string {
     return fmt.Sprintf("%s", e.msg)
 }
 
+//Unwrap : Underlying error that caused the OVO error, if any
+func (e *CustomError) Unwrap() error {
+    return e.err
+}
+
+//Temporary : Whether the condition causing the error is expected to go away on its own
+func (e *CustomError) Temporary() bool {
+    return e.temporary
+}
+
+//Retryable : Whether the failed operation can be safely sent again as is
+func (e *CustomError) Retryable() bool {
+    return e.retryable
+}
+
+//Category : Category of the error (transport, auth, validation, conflict, business)
+func (e *CustomError) Category() ErrCategory {
+    return e.category
+}
+
+func (e *PathParamError) Error() string {
+    if e.Value == "" {
+        return fmt.Sprintf("ovo: endpoint %s missing path param %s", e.Endpoint, e.Param)
+    }
+    return fmt.Sprintf("ovo: endpoint %s invalid path param %s=%q", e.Endpoint, e.Param, e.Value)
+}
+
+func (e *ConfigError) Error() string {
+    return fmt.Sprintf("ovo: config %s %s", e.Field, e.Reason)
+}
+
 //GetErrCode : Get OVO error code
 func GetErrCode(e error) int {
     if ae, ok := e.(*CustomError); ok {
@@ -22,24 +56,142 @@ func GetErrMsg(e error) string {
     return e.Error()
 }
 
-//TErr : Translate OVO related error message
-func TErr(keyword, locale string) error {
+//GetErrCategory : Get OVO error category, empty if the error is not coming from the sdk
+func GetErrCategory(e error) ErrCategory {
+    var ae *CustomError
+    if errors.As(e, &ae) {
+        return ae.category
+    }
+    return ""
+}
 
-    v, ok := ErrMessage[keyword][locale]
-    if ok {
-        return errors.New(v)
+//IsTemporary : Check if the error is expected to go away on its own
+func IsTemporary(e error) bool {
+    var ae *CustomError
+    if errors.As(e, &ae) {
+        return ae.temporary
+    }
+    return isTemporary(e)
+}
+
+//IsRetryable : Check if the operation returning the error can be safely retried
+func IsRetryable(e error) bool {
+    var ae *CustomError
+    if errors.As(e, &ae) {
+        return ae.retryable
     }
+    return isTemporary(e)
+}
 
-    return errors.New("Unknown OVO Service error")
+//TErr : Translate OVO related error message
+func TErr(keyword, locale string) error {
+    return TCustomErr(keyword, NoErrCode, locale)
 }
 
 //TCustomErr : Translate OVO related custom error message
 func TCustomErr(keyword string, errCode int, locale string) error {
 
+    kind, ok := errKinds[keyword]
+    if !ok {
+        kind = errKind{category: ErrCategoryBusiness}
+    }
+
     v, ok := ErrMessage[keyword][locale]
-    if ok {
-        return &CustomError{errCode, v}
+    if !ok {
+        v, ok = ErrMessage[keyword]["en"]
+    }
+    if !ok {
+        v = "Unknown OVO Service error"
+    }
+
+    return &CustomError{
+        code:      errCode,
+        msg:       v,
+        key:       keyword,
+        category:  kind.category,
+        temporary: kind.temporary,
+        retryable: kind.temporary,
+    }
+}
+
+//causeErr : Translated error keeping its cause, temporary when either the keyword or the cause is
+func causeErr(keyword, locale string, cause error) error {
+    e := TErr(keyword, locale).(*CustomError)
+    e.err = cause
+    if isTemporary(cause) {
+        e.temporary = true
+        e.retryable = true
+    }
+    return e
+}
+
+//transportErr : Translated transport error, retryable tells whether resending the request is safe
+func transportErr(keyword, locale string, cause error, retryable bool) error {
+    e := causeErr(keyword, locale, cause).(*CustomError)
+    e.retryable = retryable
+    return e
+}
+
+//responseErr : Error from a non success OVO api response
+func responseErr(r Response) error {
+    e := &CustomError{code: r.Code, msg: r.Message, category: ErrCategoryBusiness}
+
+    switch r.Code {
+    case DuplicateMerchantInvoice:
+        e.category = ErrCategoryConflict
+        return e
+    case AmountMustNotNegative:
+        e.category = ErrCategoryValidation
+        return e
+    case LoyaltyAccountDisabled:
+        return e
     }
 
-    return errors.New("Unknown OVO Service error")
+    switch {
+    case r.Status == http.StatusUnauthorized || r.Status == http.StatusForbidden:
+        e.category = ErrCategoryAuth
+    case r.Status == http.StatusConflict:
+        e.category = ErrCategoryConflict
+    case r.Status == http.StatusBadRequest || r.Status == http.StatusUnprocessableEntity:
+        e.category = ErrCategoryValidation
+    case r.Status == http.StatusTooManyRequests || r.Status >= http.StatusInternalServerError:
+        e.category = ErrCategoryTransport
+        e.temporary = true
+        e.retryable = true
+    }
+
+    return e
+}
+
+//wrapErr : Classify an error coming from outside the sdk (database, encoding), keeps sdk errors untouched
+func wrapErr(err error, category ErrCategory) error {
+    if err == nil {
+        return nil
+    }
+    if _, ok := err.(*CustomError); ok {
+        return err
+    }
+    temporary := isTemporary(err)
+    return &CustomError{
+        msg:       err.Error(),
+        category:  category,
+        temporary: temporary,
+        retryable: temporary,
+        err:       err,
+    }
+}
+
+func isTemporary(err error) bool {
+    if err == nil {
+        return false
+    }
+    if errors.Is(err, driver.ErrBadConn) || errors.Is(err, context.DeadlineExceeded) {
+        return true
+    }
+    var t interface{ Temporary() bool }
+    if errors.As(err, &t) && t.Temporary() {
+        return true
+    }
+    var to interface{ Timeout() bool }
+    return errors.As(err, &to) && to.Timeout()
 }
@@ -0,0 +1,116 @@
+package ovo
+
+import (
+    "database/sql/driver"
+    "errors"
+    "net/http"
+    "testing"
+)
+
+func TestTErrClassification(t *testing.T) {
+    cases := map[string]struct {
+        category  ErrCategory
+        temporary bool
+    }{
+        "ovo_unavailable_service": {ErrCategoryTransport, true},
+        "ovo_invalid_response":    {ErrCategoryTransport, false},
+        "ovo_id_used":             {ErrCategoryConflict, false},
+        "ovo_id_invalid":          {ErrCategoryValidation, false},
+        "ovo_not_authenticated":   {ErrCategoryAuth, false},
+        "ovo_unknown_keyword":     {ErrCategoryBusiness, false},
+    }
+
+    for k, v := range cases {
+        err := TErr(k, "en")
+        if GetErrCategory(err) != v.category {
+            t.Errorf("%s category must be %s", k, v.category)
+        }
+        if IsTemporary(err) != v.temporary || IsRetryable(err) != v.temporary {
+            t.Errorf("%s temporary flag is invalid", k)
+        }
+    }
+}
+
+func TestTErrKeepMessage(t *testing.T) {
+    if TErr("ovo_id_used", "en").Error() != ErrMessage["ovo_id_used"]["en"] {
+        t.Errorf("Translated message must not change")
+    }
+}
+
+func TestResponseErr(t *testing.T) {
+    err := responseErr(Response{Status: http.StatusOK, Code: DuplicateMerchantInvoice, Message: "Duplicate"})
+    if GetErrCategory(err) != ErrCategoryConflict {
+        t.Errorf("Duplicate merchant invoice should be a conflict")
+    }
+    if GetErrCode(err) != DuplicateMerchantInvoice {
+        t.Errorf("OVO code should be kept")
+    }
+
+    err = responseErr(Response{Status: http.StatusUnauthorized, Code: 99})
+    if GetErrCategory(err) != ErrCategoryAuth {
+        t.Errorf("401 response should be an auth error")
+    }
+
+    err = responseErr(Response{Status: http.StatusServiceUnavailable})
+    if !IsRetryable(err) {
+        t.Errorf("503 response should be retryable")
+    }
+}
+
+func TestWrapErr(t *testing.T) {
+    err := wrapErr(driver.ErrBadConn, ErrCategoryTransport)
+    if !IsTemporary(err) {
+        t.Errorf("Bad connection should be temporary")
+    }
+    if !errors.Is(err, driver.ErrBadConn) {
+        t.Errorf("Cause should be unwrapped")
+    }
+
+    err = wrapErr(errors.New("syntax error"), ErrCategoryTransport)
+    if IsTemporary(err) {
+        t.Errorf("Unknown database error should not be temporary")
+    }
+
+    if wrapErr(nil, ErrCategoryTransport) != nil {
+        t.Errorf("Nil error should stay nil")
+    }
+}
+
+func TestExecRequestConnectionRefusedPost(t *testing.T) {
+    client := new(Client)
+    client.LocaleID = "en"
+
+    _, err := client.execRequest("customer_authentication", "http://127.0.0.1:1", nil)
+    if !IsTemporary(err) {
+        t.Errorf("Connection error should be temporary")
+    }
+    if IsRetryable(err) {
+        t.Errorf("POST should not be retried after connection error")
+    }
+}
+
+func TestExecRequest503Post(t *testing.T) {
+    client := new(Client)
+    client.LocaleID = "en"
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusServiceUnavailable)
+    }
+
+    _, err := client.execRequest("customer_authentication", "http://apapunitu.com", nil)
+    if !IsTemporary(err) {
+        t.Errorf("503 should be temporary")
+    }
+    if IsRetryable(err) {
+        t.Errorf("POST should not be retried after 503")
+    }
+}
+
+func TestTErrUnknownLocale(t *testing.T) {
+    err := TErr("ovo_unavailable_service", "fr")
+    if err.Error() != ErrMessage["ovo_unavailable_service"]["en"] {
+        t.Errorf("Unknown locale should fall back to en message")
+    }
+    if GetErrCategory(err) != ErrCategoryTransport || !IsTemporary(err) {
+        t.Errorf("Unknown locale should keep the keyword classification")
+    }
+}
@@ -0,0 +1,104 @@
+package ovo
+
+import "time"
+
+//Subscribe : Register subscriber for linkage lifecycle events, subscribers are called synchronously in registration order
+func (c *MatahariMall) Subscribe(s LinkageSubscriber) {
+    c.subscribers = append(c.subscribers, s)
+}
+
+//publish : Call event on every subscriber
+func (c *MatahariMall) publish(event func(LinkageSubscriber, LinkageEvent), before, after *CustomerOvo) {
+    if len(c.subscribers) == 0 {
+        return
+    }
+
+    ev := LinkageEvent{Before: before, After: after, At: time.Now()}
+    if after != nil {
+        ev.CustomerID = after.CustomerID
+    } else if before != nil {
+        ev.CustomerID = before.CustomerID
+    }
+
+    for _, s := range c.subscribers {
+        event(s, ev)
+    }
+}
+
+//linkageChanged : Publish phone change and verification found between two states of a stored linkage
+func (c *MatahariMall) linkageChanged(before, after *CustomerOvo) {
+    if before != nil && after != nil && before.OvoPhone != after.OvoPhone {
+        c.publish(LinkageSubscriber.OnPhoneChanged, before, after)
+    }
+    if after != nil && after.FgVerified > 0 && (before == nil || before.FgVerified <= 0) {
+        c.funnel(FunnelVerified)
+        c.publish(LinkageSubscriber.OnLinkageVerified, before, after)
+    }
+}
+
+//snapshot : Copy of a stored linkage, nil when there is none
+func snapshot(o *CustomerOvo) *CustomerOvo {
+    if o == nil || o.CustomerID == 0 {
+        return nil
+    }
+    cp := *o
+    return &cp
+}
+
+//OnAuthenticationSent : Ignore event
+func (NopLinkageSubscriber) OnAuthenticationSent(LinkageEvent) {}
+
+//OnLinkageVerified : Ignore event
+func (NopLinkageSubscriber) OnLinkageVerified(LinkageEvent) {}
+
+//OnLinkageRejected : Ignore event
+func (NopLinkageSubscriber) OnLinkageRejected(LinkageEvent) {}
+
+//OnPhoneChanged : Ignore event
+func (NopLinkageSubscriber) OnPhoneChanged(LinkageEvent) {}
+
+//NewAsyncSubscriber : Deliver events to next from a background goroutine, publishing blocks once buffer events are queued
+func NewAsyncSubscriber(next LinkageSubscriber, buffer int) *AsyncSubscriber {
+    a := &AsyncSubscriber{
+        next:   next,
+        events: make(chan func(), buffer),
+        done:   make(chan struct{}),
+    }
+
+    go func() {
+        defer close(a.done)
+        for deliver := range a.events {
+            deliver()
+        }
+    }()
+
+    return a
+}
+
+//OnAuthenticationSent : Queue event
+func (a *AsyncSubscriber) OnAuthenticationSent(ev LinkageEvent) {
+    a.events <- func() { a.next.OnAuthenticationSent(ev) }
+}
+
+//OnLinkageVerified : Queue event
+func (a *AsyncSubscriber) OnLinkageVerified(ev LinkageEvent) {
+    a.events <- func() { a.next.OnLinkageVerified(ev) }
+}
+
+//OnLinkageRejected : Queue event
+func (a *AsyncSubscriber) OnLinkageRejected(ev LinkageEvent) {
+    a.events <- func() { a.next.OnLinkageRejected(ev) }
+}
+
+//OnPhoneChanged : Queue event
+func (a *AsyncSubscriber) OnPhoneChanged(ev LinkageEvent) {
+    a.events <- func() { a.next.OnPhoneChanged(ev) }
+}
+
+//Close : Deliver queued events and stop, no event must be published afterwards
+func (a *AsyncSubscriber) Close() {
+    a.once.Do(func() {
+        close(a.events)
+    })
+    <-a.done
+}
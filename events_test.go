@@ -0,0 +1,157 @@
+package ovo
+
+import (
+    "database/sql"
+    "net/http"
+    "testing"
+
+    sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type recordSubscriber struct {
+    events []string
+    last   LinkageEvent
+}
+
+func (s *recordSubscriber) record(name string, ev LinkageEvent) {
+    s.events = append(s.events, name)
+    s.last = ev
+}
+
+func (s *recordSubscriber) OnAuthenticationSent(ev LinkageEvent) { s.record("sent", ev) }
+func (s *recordSubscriber) OnLinkageVerified(ev LinkageEvent)    { s.record("verified", ev) }
+func (s *recordSubscriber) OnLinkageRejected(ev LinkageEvent)    { s.record("rejected", ev) }
+func (s *recordSubscriber) OnPhoneChanged(ev LinkageEvent)       { s.record("phone", ev) }
+
+func TestLinkageEventsPhoneChanged(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, nil, "081208080808", "123", 0, "hypermart")
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnRows(rows)
+    mock.ExpectQuery(`SELECT customer_id, fg_verified FROM customer_ovo`).WillReturnError(sqlmock.ErrCancelled)
+    mock.ExpectQuery(`SELECT customer_id FROM customer_ovo_unlink`).WillReturnError(sql.ErrNoRows)
+    mock.ExpectBegin()
+    mock.ExpectExec(`UPDATE customer_ovo`).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    client := new(Client)
+    client.LocaleID = "en"
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusCreated)
+        w.Write([]byte(`{"status": 201, "data": {"authentication_id": "666"}, "message": "Success", "code": 1}`))
+    }
+    mmsdk := client.GetMMsdk(db)
+    sub := &recordSubscriber{}
+    mmsdk.Subscribe(sub)
+
+    err = mmsdk.ValidateOvoIDAndAuthenticateToOvo(&Request{CustomerID: 12345, Phone: "081909090909"})
+    if err != nil {
+        t.Fatalf("This should not error expect success, got %s", err)
+    }
+
+    if len(sub.events) != 2 || sub.events[0] != "sent" || sub.events[1] != "phone" {
+        t.Errorf("Authentication sent and phone changed should be published, got %v", sub.events)
+    }
+    if sub.last.Before.OvoPhone != "081208080808" || sub.last.After.OvoPhone != "081909090909" || sub.last.After.OvoAuthID != "666" {
+        t.Errorf("Event should carry linkage before and after")
+    }
+}
+
+func TestLinkageEventsVerifiedAsync(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, nil, "081208080808", "123", 0, "hypermart")
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnRows(rows)
+    mock.ExpectBegin()
+    mock.ExpectExec(`UPDATE customer_ovo`).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    client := new(Client)
+    client.LocaleID = "en"
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`{"status": 200, "data": {"loyalty_id": "8000428048133600"}, "message": "Authenticated", "code": 1}`))
+    }
+    mmsdk := client.GetMMsdk(db)
+    sub := &recordSubscriber{}
+    async := NewAsyncSubscriber(sub, 10)
+    mmsdk.Subscribe(async)
+
+    if _, err := mmsdk.CheckOvoStatus(12345); err != nil {
+        t.Fatalf("Should not return error upon success, got %s", err)
+    }
+    async.Close()
+
+    if len(sub.events) != 1 || sub.events[0] != "verified" {
+        t.Errorf("Linkage verified should be published, got %v", sub.events)
+    }
+    if sub.last.Before.FgVerified != 0 || sub.last.After.FgVerified != 1 || sub.last.After.OvoID != "8000428048133600" {
+        t.Errorf("Event should carry linkage before and after verification")
+    }
+}
+
+func TestLinkageEventsRejected(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, nil, "081208080808", "123", 0, "hypermart")
+    mock.ExpectQuery(`SELECT customer_id`).WillReturnRows(rows)
+
+    client := new(Client)
+    client.LocaleID = "en"
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusNotFound)
+        w.Write([]byte(`{"status": 404, "data": [], "message": "Authentication not found", "code": 3}`))
+    }
+    mmsdk := client.GetMMsdk(db)
+    sub := &recordSubscriber{}
+    mmsdk.Subscribe(sub)
+
+    _, err = mmsdk.CheckOvoStatus(12345)
+    if err == nil || err.Error() != TErr("ovo_retry_verification", client.LocaleID).Error() {
+        t.Errorf("Unknown authentication should ask to retry verification, got %v", err)
+    }
+    if len(sub.events) != 1 || sub.events[0] != "rejected" || sub.last.CustomerID != 12345 {
+        t.Errorf("Linkage rejected should be published, got %v", sub.events)
+    }
+}
+
+func TestLinkageEventsPending(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, nil, "081208080808", "123", 0, "hypermart")
+    mock.ExpectQuery(`SELECT customer_id`).WillReturnRows(rows)
+
+    client := new(Client)
+    client.LocaleID = "en"
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`{"status": 200, "data": [], "message": "Unauthenticated", "code": 2}`))
+    }
+    mmsdk := client.GetMMsdk(db)
+    sub := &recordSubscriber{}
+    mmsdk.Subscribe(sub)
+
+    _, err = mmsdk.CheckOvoStatus(12345)
+    if err == nil || err.Error() != TErr("ovo_retry_verification", client.LocaleID).Error() {
+        t.Errorf("Unauthenticated should ask to retry verification, got %v", err)
+    }
+    if len(sub.events) != 0 {
+        t.Errorf("Pending authentication should not be published as rejected, got %v", sub.events)
+    }
+}
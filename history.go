@@ -0,0 +1,147 @@
+package ovo
+
+import "database/sql"
+
+//addHistory : Record a customer_ovo mutation, before is nil for a new linkage and after is nil for a removed one
+func (c *MatahariMall) addHistory(db execer, action HistoryAction, before, after *CustomerOvo, actor, reason string) error {
+    var old, cur CustomerOvo
+    if before != nil {
+        old = *before
+    }
+    if after != nil {
+        cur = *after
+    }
+
+    customerID := cur.CustomerID
+    if customerID == 0 {
+        customerID = old.CustomerID
+    }
+
+    oldPhone, err := c.sealPhone(old.OvoPhone)
+    if err != nil {
+        return err
+    }
+    newPhone, err := c.sealPhone(cur.OvoPhone)
+    if err != nil {
+        return err
+    }
+
+    sqlInsert := `INSERT INTO
+                    customer_ovo_history(
+                        customer_id,
+                        action,
+                        old_ovo_id,
+                        new_ovo_id,
+                        old_ovo_phone,
+                        new_ovo_phone,
+                        old_ovo_auth_id,
+                        new_ovo_auth_id,
+                        old_fg_verified,
+                        new_fg_verified,
+                        actor,
+                        source,
+                        reason,
+                        created_at
+                    )
+                  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())`
+    end := c.sqlSpan("INSERT", "customer_ovo_history")
+    _, err = db.Exec(sqlInsert,
+        customerID,
+        string(action),
+        old.OvoID,
+        cur.OvoID,
+        oldPhone,
+        newPhone,
+        old.OvoAuthID,
+        cur.OvoAuthID,
+        old.FgVerified,
+        cur.FgVerified,
+        actor,
+        c.API.AppID,
+        reason,
+    )
+    end(err)
+    if err != nil {
+        return wrapErr(err, ErrCategoryTransport)
+    }
+
+    return nil
+}
+
+//LinkageHistory : Linkage timeline of the customer, oldest change first
+func (c *MatahariMall) LinkageHistory(customerID int64) ([]CustomerOvoHistory, error) {
+    q := `SELECT id,
+                   customer_id,
+                   action,
+                   old_ovo_id,
+                   new_ovo_id,
+                   old_ovo_phone,
+                   new_ovo_phone,
+                   old_ovo_auth_id,
+                   new_ovo_auth_id,
+                   old_fg_verified,
+                   new_fg_verified,
+                   actor,
+                   source,
+                   reason,
+                   created_at
+            FROM customer_ovo_history
+            WHERE customer_id = ?
+            ORDER BY created_at, id`
+
+    end := c.sqlSpan("SELECT", "customer_ovo_history")
+    rows, err := c.DB.Query(q, customerID)
+    if err != nil {
+        end(err)
+        return nil, wrapErr(err, ErrCategoryTransport)
+    }
+    defer rows.Close()
+
+    var history []CustomerOvoHistory
+    for rows.Next() {
+        var h CustomerOvoHistory
+        var action string
+        var createdAt sql.NullTime
+        err = rows.Scan(
+            &h.ID,
+            &h.CustomerID,
+            &action,
+            &h.OldOvoID,
+            &h.NewOvoID,
+            &h.OldOvoPhone,
+            &h.NewOvoPhone,
+            &h.OldOvoAuthID,
+            &h.NewOvoAuthID,
+            &h.OldFgVerified,
+            &h.NewFgVerified,
+            &h.Actor,
+            &h.Source,
+            &h.Reason,
+            &createdAt,
+        )
+        if err != nil {
+            end(err)
+            return nil, wrapErr(err, ErrCategoryTransport)
+        }
+        h.Action = HistoryAction(action)
+        if h.OldOvoPhone, err = c.openPhone(h.OldOvoPhone); err != nil {
+            end(err)
+            return nil, err
+        }
+        if h.NewOvoPhone, err = c.openPhone(h.NewOvoPhone); err != nil {
+            end(err)
+            return nil, err
+        }
+        if createdAt.Valid {
+            h.CreatedAt = &createdAt.Time
+        }
+        history = append(history, h)
+    }
+    err = rows.Err()
+    end(err)
+    if err != nil {
+        return nil, wrapErr(err, ErrCategoryTransport)
+    }
+
+    return history, nil
+}
@@ -0,0 +1,65 @@
+package ovo
+
+import (
+    "testing"
+    "time"
+
+    sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestSaveToDatabaseHistory(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, "", "081208080808", "123", 0, "hypermart")
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnRows(rows)
+    mock.ExpectBegin()
+    mock.ExpectExec(`UPDATE customer_ovo`).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WithArgs(12345, "update", "", "", "081208080808", "081909090909", "123", "123", 0, 0, "customer", "hypermart", "authentication_sent").WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    client := new(Client)
+    client.LocaleID = "en"
+    client.AppID = "hypermart"
+    mmsdk := client.GetMMsdk(db)
+
+    mmsdk.OvoReq = &Request{CustomerID: 12345, Phone: "081909090909", Actor: "customer", Reason: ReasonAuthenticationSent}
+    if err := mmsdk.getOvoInfoFromStorage(mmsdk.OvoReq); err != nil {
+        t.Fatal(err)
+    }
+    if err := mmsdk.saveToDatabase(); err != nil {
+        t.Errorf("This should not error, got %s", err)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("This should record old and new phone: %s", err)
+    }
+}
+
+func TestLinkageHistory(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    now := time.Now()
+    rows := sqlmock.NewRows([]string{"id", "customer_id", "action", "old_ovo_id", "new_ovo_id", "old_ovo_phone", "new_ovo_phone", "old_ovo_auth_id", "new_ovo_auth_id", "old_fg_verified", "new_fg_verified", "actor", "source", "reason", "created_at"}).
+        AddRow(1, 12345, "insert", "", "", "", "081208080808", "", "123", 0, 0, "customer", "hypermart", "authentication_sent", now).
+        AddRow(2, 12345, "update", "", "6789", "081208080808", "081208080808", "123", "123", 0, 1, "ovo", "hypermart", "authentication_callback", now)
+    mock.ExpectQuery(`SELECT id, customer_id, action`).WithArgs(12345).WillReturnRows(rows)
+
+    client := new(Client)
+    client.LocaleID = "en"
+    mmsdk := client.GetMMsdk(db)
+
+    history, err := mmsdk.LinkageHistory(12345)
+    if err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    if len(history) != 2 || history[0].Action != HistoryInsert || history[1].NewOvoID != "6789" || history[1].NewFgVerified != 1 {
+        t.Errorf("This should return the linkage timeline, got %+v", history)
+    }
+}
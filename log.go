@@ -0,0 +1,151 @@
+package ovo
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+)
+
+var (
+    //digitsRegex : Phone numbers and OVO loyalty ids, in urls and messages too
+    digitsRegex = regexp.MustCompile(`\+?[0-9]{9,}`)
+
+    secretKeys = map[string]bool{
+        "api_key":       true,
+        "apikey":        true,
+        "hmac":          true,
+        "authorization": true,
+        "secret":        true,
+        "password":      true,
+    }
+
+    phoneKeys = map[string]bool{
+        "phone":          true,
+        "ovo_phone":      true,
+        "customer_phone": true,
+    }
+)
+
+//SetLogger : Setting logger, values are redacted before reaching it. Nothing is logged when not set
+func (client *Client) SetLogger(l Logger) {
+    client.logger = newRedactLogger(l, client)
+}
+
+//SetLogger : Setting logger of the sdk, the client logger is used when not set
+func (c *MatahariMall) SetLogger(l Logger) {
+    c.logger = newRedactLogger(l, c.API)
+}
+
+func (client *Client) log() Logger {
+    if client == nil || client.logger == nil {
+        return nopLogger{}
+    }
+    return client.logger
+}
+
+func (c *MatahariMall) log() Logger {
+    if c.logger != nil {
+        return c.logger
+    }
+    return c.API.log()
+}
+
+func newRedactLogger(l Logger, client *Client) Logger {
+    if l == nil {
+        return nil
+    }
+    return &redactLogger{next: l, client: client}
+}
+
+//Debug : Log redacted message
+func (l *redactLogger) Debug(msg string, args ...interface{}) {
+    l.next.Debug(l.redactString(msg), l.redact(args)...)
+}
+
+//Info : Log redacted message
+func (l *redactLogger) Info(msg string, args ...interface{}) {
+    l.next.Info(l.redactString(msg), l.redact(args)...)
+}
+
+//Warn : Log redacted message
+func (l *redactLogger) Warn(msg string, args ...interface{}) {
+    l.next.Warn(l.redactString(msg), l.redact(args)...)
+}
+
+//Error : Log redacted message
+func (l *redactLogger) Error(msg string, args ...interface{}) {
+    l.next.Error(l.redactString(msg), l.redact(args)...)
+}
+
+//redact : Copy of key value pairs with secrets removed and phone numbers masked
+func (l *redactLogger) redact(args []interface{}) []interface{} {
+    out := make([]interface{}, len(args))
+    for i, v := range args {
+        if i%2 == 1 {
+            key, _ := args[i-1].(string)
+            key = strings.ToLower(key)
+            switch {
+            case secretKeys[key]:
+                out[i] = "[REDACTED]"
+                continue
+            case phoneKeys[key]:
+                out[i] = maskDigits(fmt.Sprint(v))
+                continue
+            }
+        }
+
+        switch val := v.(type) {
+        case string:
+            out[i] = l.redactString(val)
+        case error:
+            out[i] = l.redactString(val.Error())
+        case fmt.Stringer:
+            out[i] = l.redactString(val.String())
+        default:
+            out[i] = v
+        }
+    }
+    return out
+}
+
+func (l *redactLogger) redactString(s string) string {
+    if l.client != nil {
+        for _, key := range l.client.secrets() {
+            if key != "" {
+                s = strings.Replace(s, key, "[REDACTED]", -1)
+            }
+        }
+    }
+    return digitsRegex.ReplaceAllStringFunc(s, maskDigits)
+}
+
+//secrets : Api key of the client and the current and previous api keys of its CredentialProvider
+func (client *Client) secrets() []string {
+    keys := []string{client.APIKey}
+    if client.credentials != nil {
+        if creds, err := client.credentials.Credentials(); err == nil {
+            keys = append(keys, creds.Keys()...)
+        }
+    }
+    return keys
+}
+
+//maskDigits : Keep the last 3 characters only
+func maskDigits(s string) string {
+    if len(s) <= 3 {
+        return strings.Repeat("*", len(s))
+    }
+    return strings.Repeat("*", len(s)-3) + s[len(s)-3:]
+}
+
+//Debug : Ignore message
+func (nopLogger) Debug(string, ...interface{}) {}
+
+//Info : Ignore message
+func (nopLogger) Info(string, ...interface{}) {}
+
+//Warn : Ignore message
+func (nopLogger) Warn(string, ...interface{}) {}
+
+//Error : Ignore message
+func (nopLogger) Error(string, ...interface{}) {}
@@ -0,0 +1,7 @@
+//go:build go1.21
+
+package ovo
+
+import "log/slog"
+
+var _ Logger = (*slog.Logger)(nil)
@@ -0,0 +1,84 @@
+package ovo
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "testing"
+)
+
+type recordLogger struct {
+    lines []string
+}
+
+func (l *recordLogger) record(level, msg string, args ...interface{}) {
+    l.lines = append(l.lines, strings.TrimSpace(fmt.Sprintln(append([]interface{}{level, msg}, args...)...)))
+}
+
+func (l *recordLogger) Debug(msg string, args ...interface{}) { l.record("DEBUG", msg, args...) }
+func (l *recordLogger) Info(msg string, args ...interface{})  { l.record("INFO", msg, args...) }
+func (l *recordLogger) Warn(msg string, args ...interface{})  { l.record("WARN", msg, args...) }
+func (l *recordLogger) Error(msg string, args ...interface{}) { l.record("ERROR", msg, args...) }
+
+func TestLoggerRedaction(t *testing.T) {
+    client := New("http://testing.com", "supersecretkey", "hypermart", "1")
+    rec := &recordLogger{}
+    client.SetLogger(rec)
+
+    client.log().Info("linking 081234567890",
+        "phone", "0812",
+        "hmac", "abcdef",
+        "url", "http://testing.com/customers/081234567890",
+        "error", errors.New("bad key supersecretkey"),
+        "customer_id", int64(12345),
+    )
+
+    line := rec.lines[0]
+    for _, leak := range []string{"081234567890", "0812 ", "abcdef", "supersecretkey"} {
+        if strings.Contains(line, leak) {
+            t.Errorf("Log should not contain %q: %s", leak, line)
+        }
+    }
+    if !strings.Contains(line, "*********890") || !strings.Contains(line, "12345") {
+        t.Errorf("Log should keep masked phone and customer id: %s", line)
+    }
+}
+
+func TestLoggerRedactionCredentials(t *testing.T) {
+    client := New("http://testing.com", "", "hypermart", "1")
+    client.SetCredentialProvider(CredentialProviderFunc(func() (Credentials, error) {
+        return Credentials{AppID: "hypermart", APIKey: "currentsecret", PreviousAPIKey: "previoussecret"}, nil
+    }))
+    rec := &recordLogger{}
+    client.SetLogger(rec)
+
+    client.log().Warn("signing failed", "error", errors.New("keys currentsecret previoussecret"))
+
+    for _, leak := range []string{"currentsecret", "previoussecret"} {
+        if strings.Contains(rec.lines[0], leak) {
+            t.Errorf("Log should not contain %q: %s", leak, rec.lines[0])
+        }
+    }
+}
+
+func TestExecRequestLog(t *testing.T) {
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`{"status": 200}`))
+    }
+    rec := &recordLogger{}
+    client.SetLogger(rec)
+
+    mmsdk := client.GetMMsdk(nil)
+    if _, err := mmsdk.API.GetCustomerProfile("081234567890"); err != nil {
+        t.Fatal(err)
+    }
+
+    if len(rec.lines) != 1 || !strings.HasPrefix(rec.lines[0], "DEBUG ovo request endpoint customer_profile") {
+        t.Errorf("Request should be logged with its endpoint, got %v", rec.lines)
+    }
+    if mmsdk.log() != client.logger {
+        t.Errorf("Sdk should use the client logger when not set")
+    }
+}
@@ -0,0 +1,112 @@
+package ovo
+
+import (
+    "database/sql"
+
+    "github.com/kh411d/ovo/phone"
+)
+
+//phoneLookup : Value of ovo_phone_lookup for a phone in any format, E.164 or its blind index when PhoneCipher is set.
+//Empty when the phone cannot be normalized
+func (c *MatahariMall) phoneLookup(p string) string {
+    e164, err := phone.Normalize(p)
+    if err != nil {
+        return ""
+    }
+    if c.PhoneCipher != nil {
+        return c.PhoneCipher.Index(e164)
+    }
+    return e164
+}
+
+func nullString(s string) sql.NullString {
+    return sql.NullString{String: s, Valid: s != ""}
+}
+
+//BackfillPhoneLookup : Fill ovo_phone_lookup of customer_ovo and customer_ovo_unlink rows written before the column existed, batchSize rows per query.
+//Rows with a phone that cannot be normalized keep a NULL lookup, their customer id is returned in Invalid.
+//Safe to run again, only rows without lookup are read
+func (c *MatahariMall) BackfillPhoneLookup(batchSize int) (BackfillResult, error) {
+    var res BackfillResult
+    if batchSize <= 0 {
+        batchSize = DefaultBackfillBatch
+    }
+
+    err := c.backfillPhoneLookup("customer_ovo", "customer_id", batchSize, &res)
+    if err != nil {
+        return res, err
+    }
+
+    err = c.backfillPhoneLookup("customer_ovo_unlink", "id", batchSize, &res)
+    return res, err
+}
+
+func (c *MatahariMall) backfillPhoneLookup(table, key string, batchSize int, res *BackfillResult) error {
+    type row struct {
+        key        int64
+        customerID int64
+        phone      string
+    }
+
+    q := `SELECT ` + key + `, customer_id, ovo_phone
+            FROM ` + table + `
+           WHERE ovo_phone_lookup IS NULL
+             AND ` + key + ` > ?
+           ORDER BY ` + key + `
+           LIMIT ?`
+    sqlUpdate := `UPDATE ` + table + ` SET ovo_phone_lookup = ? WHERE ` + key + ` = ?`
+
+    var last int64
+    for {
+        end := c.sqlSpan("SELECT", table)
+        rows, err := c.DB.Query(q, last, batchSize)
+        if err != nil {
+            end(err)
+            return wrapErr(err, ErrCategoryTransport)
+        }
+
+        var batch []row
+        for rows.Next() {
+            var r row
+            if err = rows.Scan(&r.key, &r.customerID, &r.phone); err != nil {
+                rows.Close()
+                end(err)
+                return wrapErr(err, ErrCategoryTransport)
+            }
+            batch = append(batch, r)
+        }
+        err = rows.Err()
+        rows.Close()
+        end(err)
+        if err != nil {
+            return wrapErr(err, ErrCategoryTransport)
+        }
+
+        for _, r := range batch {
+            last = r.key
+
+            p, err := c.openPhone(r.phone)
+            if err != nil {
+                return err
+            }
+
+            lookup := c.phoneLookup(p)
+            if lookup == "" {
+                res.Invalid = append(res.Invalid, r.customerID)
+                continue
+            }
+
+            end := c.sqlSpan("UPDATE", table)
+            _, err = c.DB.Exec(sqlUpdate, lookup, r.key)
+            end(err)
+            if err != nil {
+                return wrapErr(err, ErrCategoryTransport)
+            }
+            res.Updated++
+        }
+
+        if len(batch) < batchSize {
+            return nil
+        }
+    }
+}
@@ -0,0 +1,65 @@
+package ovo
+
+import (
+    "testing"
+
+    sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestGetCustomerOvoByPhoneLookup(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    for i := 0; i < 3; i++ {
+        rows := sqlmock.NewRows([]string{"customer_id", "fg_verified"}).AddRow(1, 0)
+        mock.ExpectQuery(`SELECT customer_id, fg_verified FROM customer_ovo WHERE ovo_phone_lookup`).WithArgs("+6281234567890").WillReturnRows(rows)
+    }
+
+    client := new(Client)
+    mmsdk := client.GetMMsdk(db)
+
+    for _, p := range []string{"081234567890", "6281234567890", "+62 812-3456-7890"} {
+        if _, _, err := mmsdk.getCustomerOvoByPhone(p); err != nil {
+            t.Errorf("%s should be looked up as +6281234567890, got %s", p, err)
+        }
+    }
+}
+
+func TestBackfillPhoneLookup(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    rows := sqlmock.NewRows([]string{"customer_id", "customer_id", "ovo_phone"}).
+        AddRow(1, 1, "081234567890").
+        AddRow(2, 2, "6281234567891")
+    mock.ExpectQuery(`SELECT customer_id, customer_id, ovo_phone FROM customer_ovo WHERE ovo_phone_lookup IS NULL`).WithArgs(0, 2).WillReturnRows(rows)
+    mock.ExpectExec(`UPDATE customer_ovo SET ovo_phone_lookup`).WithArgs("+6281234567890", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`UPDATE customer_ovo SET ovo_phone_lookup`).WithArgs("+6281234567891", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+    rows = sqlmock.NewRows([]string{"customer_id", "customer_id", "ovo_phone"}).AddRow(3, 3, "021-555")
+    mock.ExpectQuery(`SELECT customer_id, customer_id, ovo_phone FROM customer_ovo WHERE ovo_phone_lookup IS NULL`).WithArgs(2, 2).WillReturnRows(rows)
+
+    rows = sqlmock.NewRows([]string{"id", "customer_id", "ovo_phone"}).AddRow(7, 4, "0812-3456-7892")
+    mock.ExpectQuery(`SELECT id, customer_id, ovo_phone FROM customer_ovo_unlink`).WithArgs(0, 2).WillReturnRows(rows)
+    mock.ExpectExec(`UPDATE customer_ovo_unlink SET ovo_phone_lookup`).WithArgs("+6281234567892", 7).WillReturnResult(sqlmock.NewResult(0, 1))
+
+    client := new(Client)
+    mmsdk := client.GetMMsdk(db)
+
+    res, err := mmsdk.BackfillPhoneLookup(2)
+    if err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    if res.Updated != 3 || len(res.Invalid) != 1 || res.Invalid[0] != 3 {
+        t.Errorf("This should update 3 rows and report customer 3 as invalid, got %+v", res)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("there were unfulfilled expectations: %s", err)
+    }
+}
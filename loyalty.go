@@ -0,0 +1,136 @@
+package ovo
+
+import (
+    "database/sql"
+    "strconv"
+    "time"
+)
+
+//SyncProfiles : Refresh level, fullname and point total of verified linkages not synced for maxAge, batchSize rows per query
+//and at most concurrency OVO lookups in flight. Accounts OVO reports as disabled are flagged, see IsOvoPaymentEnabled.
+//Profiles are always read from OVO, not from the profile cache
+func (c *MatahariMall) SyncProfiles(batchSize, concurrency int, maxAge time.Duration) (SyncResult, error) {
+    var res SyncResult
+    if batchSize <= 0 {
+        batchSize = DefaultBackfillBatch
+    }
+    if maxAge <= 0 {
+        maxAge = DefaultSyncMaxAge
+    }
+
+    api := *c.API
+    api.profileCache = nil
+
+    q := `SELECT customer_id, ovo_id
+            FROM customer_ovo
+           WHERE fg_verified = 1
+             AND ovo_id IS NOT NULL
+             AND (last_synced_at IS NULL OR last_synced_at < ?)
+             AND customer_id > ?
+           ORDER BY customer_id
+           LIMIT ?`
+    sqlSynced := `UPDATE customer_ovo
+                     SET ovo_level = ?, ovo_fullname = ?, ovo_point_total = ?, fg_disabled = 0, last_synced_at = ?
+                   WHERE customer_id = ?`
+    sqlDisabled := `UPDATE customer_ovo SET fg_disabled = 1, last_synced_at = ? WHERE customer_id = ?`
+
+    before := time.Now().Add(-maxAge)
+    var last int64
+    for {
+        end := c.sqlSpan("SELECT", "customer_ovo")
+        rows, err := c.DB.Query(q, before, last, batchSize)
+        if err != nil {
+            end(err)
+            return res, wrapErr(err, ErrCategoryTransport)
+        }
+
+        var customerIDs []int64
+        var ovoIDs []string
+        for rows.Next() {
+            var customerID int64
+            var ovoID string
+            if err = rows.Scan(&customerID, &ovoID); err != nil {
+                rows.Close()
+                end(err)
+                return res, wrapErr(err, ErrCategoryTransport)
+            }
+            customerIDs = append(customerIDs, customerID)
+            ovoIDs = append(ovoIDs, ovoID)
+        }
+        err = rows.Err()
+        rows.Close()
+        end(err)
+        if err != nil {
+            return res, wrapErr(err, ErrCategoryTransport)
+        }
+        if len(customerIDs) == 0 {
+            break
+        }
+        last = customerIDs[len(customerIDs)-1]
+
+        //Read the whole batch before writing so no lookup is left blocked when a write fails
+        var results []ProfileResult
+        for r := range api.GetCustomerProfiles(ovoIDs, concurrency) {
+            results = append(results, r)
+        }
+
+        for _, r := range results {
+            customerID := customerIDs[r.Index]
+
+            switch {
+            case r.Err == nil:
+                end := c.sqlSpan("UPDATE", "customer_ovo")
+                _, err = c.DB.Exec(sqlSynced, r.Profile.Level, r.Profile.Fullname, pointTotal(r.Profile.PointTotal), time.Now(), customerID)
+                end(err)
+                if err != nil {
+                    return res, wrapErr(err, ErrCategoryTransport)
+                }
+                res.Synced++
+            case GetErrCode(r.Err) == LoyaltyAccountDisabled:
+                end := c.sqlSpan("UPDATE", "customer_ovo")
+                _, err = c.DB.Exec(sqlDisabled, time.Now(), customerID)
+                end(err)
+                if err != nil {
+                    return res, wrapErr(err, ErrCategoryTransport)
+                }
+                res.Disabled = append(res.Disabled, customerID)
+            default:
+                c.log().Warn("ovo profile not synced", "customer_id", customerID, "ovo_id", r.CustomerID, "error", r.Err)
+                res.Failed = append(res.Failed, customerID)
+            }
+        }
+
+        if len(customerIDs) < batchSize {
+            break
+        }
+    }
+
+    c.log().Info("ovo profile sync done", "synced", res.Synced, "disabled", len(res.Disabled), "failed", len(res.Failed))
+    return res, nil
+}
+
+//IsOvoPaymentEnabled : Check if OVO payment can be offered to the customer, the linkage is verified and not disabled by OVO
+func (c *MatahariMall) IsOvoPaymentEnabled(customerID int64) (bool, error) {
+    var verified, disabled int
+    q := `SELECT fg_verified, fg_disabled
+            FROM customer_ovo
+           WHERE customer_id = ?`
+
+    end := c.sqlSpan("SELECT", "customer_ovo")
+    err := c.DB.QueryRow(q, customerID).Scan(&verified, &disabled)
+    end(err)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return false, nil
+        }
+        return false, wrapErr(err, ErrCategoryTransport)
+    }
+
+    return verified == 1 && disabled == 0, nil
+}
+
+//pointTotal : Point total of the profile, NULL when OVO sends none
+func pointTotal(s string) sql.NullInt64 {
+    n, err := strconv.ParseInt(s, 10, 64)
+    return sql.NullInt64{Int64: n, Valid: err == nil}
+}
@@ -0,0 +1,78 @@
+package ovo
+
+import (
+    "database/sql"
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+
+    sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestSyncProfiles(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    mock.ExpectQuery(`SELECT customer_id, ovo_id FROM customer_ovo`).WithArgs(sqlmock.AnyArg(), 0, 2).
+        WillReturnRows(sqlmock.NewRows([]string{"customer_id", "ovo_id"}).AddRow(12345, "8000000000000001").AddRow(12346, "8000000000000002"))
+    mock.ExpectExec(`UPDATE customer_ovo SET ovo_level = \?, ovo_fullname = \?, ovo_point_total = \?, fg_disabled = 0, last_synced_at = \?`).
+        WithArgs("OVO Premier", "Budi", sql.NullInt64{Int64: 1500, Valid: true}, sqlmock.AnyArg(), 12345).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`UPDATE customer_ovo SET fg_disabled = 1`).WithArgs(sqlmock.AnyArg(), 12346).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectQuery(`SELECT customer_id, ovo_id FROM customer_ovo`).WithArgs(sqlmock.AnyArg(), 12346, 2).
+        WillReturnRows(sqlmock.NewRows([]string{"customer_id", "ovo_id"}).AddRow(12347, "8000000000000003"))
+
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        switch {
+        case strings.HasSuffix(r.URL.Path, "0001"):
+            w.Write([]byte(`{"status": 200, "data": {"loyalty_id": "8000000000000001", "fullname": "Budi", "level": "OVO Premier", "point_total": "1500"}, "message": "Success", "code": 1}`))
+        case strings.HasSuffix(r.URL.Path, "0002"):
+            w.WriteHeader(http.StatusForbidden)
+            w.Write([]byte(`{"status": 403, "data": [], "message": "Loyalty account is disabled", "code": 11}`))
+        default:
+            w.WriteHeader(http.StatusServiceUnavailable)
+        }
+    }
+    client.SetProfileCache(NewLRUProfileCache(10), time.Hour, 0)
+    client.GetCustomerProfile("8000000000000001")
+
+    res, err := client.GetMMsdk(db).SyncProfiles(2, 1, time.Hour)
+    if err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    if res.Synced != 1 || len(res.Disabled) != 1 || res.Disabled[0] != 12346 || len(res.Failed) != 1 || res.Failed[0] != 12347 {
+        t.Errorf("Profiles should be synced, disabled or failed, got %+v", res)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("there were unfulfilled expectations: %s", err)
+    }
+}
+
+func TestIsOvoPaymentEnabled(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    mock.ExpectQuery(`SELECT fg_verified, fg_disabled`).WithArgs(12345).WillReturnRows(sqlmock.NewRows([]string{"fg_verified", "fg_disabled"}).AddRow(1, 0))
+    mock.ExpectQuery(`SELECT fg_verified, fg_disabled`).WithArgs(12346).WillReturnRows(sqlmock.NewRows([]string{"fg_verified", "fg_disabled"}).AddRow(1, 1))
+    mock.ExpectQuery(`SELECT fg_verified, fg_disabled`).WithArgs(12347).WillReturnRows(sqlmock.NewRows([]string{"fg_verified", "fg_disabled"}).AddRow(0, 0))
+    mock.ExpectQuery(`SELECT fg_verified, fg_disabled`).WithArgs(12348).WillReturnError(sql.ErrNoRows)
+
+    mmsdk := new(Client).GetMMsdk(db)
+    cases := []struct {
+        customerID int64
+        enabled    bool
+    }{{12345, true}, {12346, false}, {12347, false}, {12348, false}}
+    for _, c := range cases {
+        enabled, err := mmsdk.IsOvoPaymentEnabled(c.customerID)
+        if err != nil || enabled != c.enabled {
+            t.Errorf("OVO payment of %d should be enabled %v, got %v %v", c.customerID, c.enabled, enabled, err)
+        }
+    }
+}
@@ -0,0 +1,182 @@
+package ovo
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+//SetMetrics : Setting metrics recorder of OVO calls and linkage funnel
+func (client *Client) SetMetrics(m Metrics) {
+    client.metrics = m
+}
+
+func (client *Client) getMetrics() Metrics {
+    if client == nil || client.metrics == nil {
+        return nopMetrics{}
+    }
+    return client.metrics
+}
+
+//funnel : Count linkage funnel step
+func (c *MatahariMall) funnel(step FunnelStep) {
+    c.API.getMetrics().IncLinkage(step)
+}
+
+//observeRequest : Record OVO call, with the OVO code of the response body when there is one
+func (client *Client) observeRequest(endpoint, method string, resp *http.Response, data []byte, duration time.Duration) {
+    if client.metrics == nil {
+        return
+    }
+
+    var status int
+    if resp != nil {
+        status = resp.StatusCode
+    }
+
+    client.metrics.ObserveRequest(endpoint, method, status, responseCode(data), duration)
+}
+
+//responseCode : OVO code of a response body, 0 when it has none
+func responseCode(data []byte) int {
+    var r struct {
+        Code int `json:"code"`
+    }
+    if len(data) == 0 || json.Unmarshal(data, &r) != nil {
+        return 0
+    }
+    return r.Code
+}
+
+//ObserveRequest : Ignore call
+func (nopMetrics) ObserveRequest(string, string, int, int, time.Duration) {}
+
+//IncLinkage : Ignore step
+func (nopMetrics) IncLinkage(FunnelStep) {}
+
+//NewPrometheusMetrics : Constructor for PrometheusMetrics, DefaultLatencyBuckets when buckets are not given
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+    if len(buckets) == 0 {
+        buckets = DefaultLatencyBuckets
+    }
+    sorted := append([]float64(nil), buckets...)
+    sort.Float64s(sorted)
+
+    return &PrometheusMetrics{
+        buckets:   sorted,
+        requests:  map[requestKey]uint64{},
+        latencies: map[string]*histogram{},
+        linkages:  map[FunnelStep]uint64{},
+    }
+}
+
+//ObserveRequest : Count request and observe its latency
+func (m *PrometheusMetrics) ObserveRequest(endpoint, method string, status, code int, duration time.Duration) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    m.requests[requestKey{endpoint, method, status, code}]++
+
+    h, ok := m.latencies[endpoint]
+    if !ok {
+        h = &histogram{counts: make([]uint64, len(m.buckets))}
+        m.latencies[endpoint] = h
+    }
+    seconds := duration.Seconds()
+    for i, le := range m.buckets {
+        if seconds <= le {
+            h.counts[i]++
+        }
+    }
+    h.count++
+    h.sum += seconds
+}
+
+//IncLinkage : Count linkage funnel step
+func (m *PrometheusMetrics) IncLinkage(step FunnelStep) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    m.linkages[step]++
+}
+
+//ServeHTTP : Write metrics in Prometheus text format
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+    m.WriteTo(w)
+}
+
+//WriteTo : Write metrics in Prometheus text format
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    buf := &bytes.Buffer{}
+
+    buf.WriteString("# HELP ovo_requests_total OVO api calls by endpoint, HTTP status and OVO response code.\n")
+    buf.WriteString("# TYPE ovo_requests_total counter\n")
+    keys := make([]requestKey, 0, len(m.requests))
+    for k := range m.requests {
+        keys = append(keys, k)
+    }
+    sort.Slice(keys, func(i, j int) bool {
+        a, b := keys[i], keys[j]
+        if a.endpoint != b.endpoint {
+            return a.endpoint < b.endpoint
+        }
+        if a.method != b.method {
+            return a.method < b.method
+        }
+        if a.status != b.status {
+            return a.status < b.status
+        }
+        return a.code < b.code
+    })
+    for _, k := range keys {
+        fmt.Fprintf(buf, "ovo_requests_total{endpoint=%q,method=%q,status=\"%d\",code=\"%d\"} %d\n", k.endpoint, k.method, k.status, k.code, m.requests[k])
+    }
+
+    buf.WriteString("# HELP ovo_request_duration_seconds OVO api call latency by endpoint.\n")
+    buf.WriteString("# TYPE ovo_request_duration_seconds histogram\n")
+    endpoints := make([]string, 0, len(m.latencies))
+    for e := range m.latencies {
+        endpoints = append(endpoints, e)
+    }
+    sort.Strings(endpoints)
+    for _, e := range endpoints {
+        h := m.latencies[e]
+        for i, le := range m.buckets {
+            fmt.Fprintf(buf, "ovo_request_duration_seconds_bucket{endpoint=%q,le=%q} %d\n", e, formatFloat(le), h.counts[i])
+        }
+        fmt.Fprintf(buf, "ovo_request_duration_seconds_bucket{endpoint=%q,le=\"+Inf\"} %d\n", e, h.count)
+        fmt.Fprintf(buf, "ovo_request_duration_seconds_sum{endpoint=%q} %s\n", e, formatFloat(h.sum))
+        fmt.Fprintf(buf, "ovo_request_duration_seconds_count{endpoint=%q} %d\n", e, h.count)
+    }
+
+    buf.WriteString("# HELP ovo_linkage_total Customer linkage funnel steps.\n")
+    buf.WriteString("# TYPE ovo_linkage_total counter\n")
+    steps := make([]string, 0, len(m.linkages))
+    for s := range m.linkages {
+        steps = append(steps, string(s))
+    }
+    sort.Strings(steps)
+    for _, s := range steps {
+        fmt.Fprintf(buf, "ovo_linkage_total{step=%q} %d\n", s, m.linkages[FunnelStep(s)])
+    }
+
+    return buf.WriteTo(w)
+}
+
+func formatFloat(f float64) string {
+    s := strconv.FormatFloat(f, 'g', -1, 64)
+    if strings.ContainsAny(s, "e") {
+        return strconv.FormatFloat(f, 'f', -1, 64)
+    }
+    return s
+}
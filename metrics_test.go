@@ -0,0 +1,42 @@
+package ovo
+
+import (
+    "bytes"
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusNotFound)
+        w.Write([]byte(`{"status": 404, "code": 17, "message": "Customer not found"}`))
+    }
+    m := NewPrometheusMetrics(0.1, 1)
+    client.SetMetrics(m)
+
+    client.GetCustomerProfile("081234567890")
+    m.ObserveRequest("customer_profile", "GET", 200, 1, 2*time.Second)
+    client.GetMMsdk(nil).funnel(FunnelAuthSent)
+
+    buf := &bytes.Buffer{}
+    m.WriteTo(buf)
+    out := buf.String()
+
+    expected := []string{
+        `ovo_requests_total{endpoint="customer_profile",method="GET",status="200",code="1"} 1`,
+        `ovo_requests_total{endpoint="customer_profile",method="GET",status="404",code="17"} 1`,
+        `ovo_request_duration_seconds_bucket{endpoint="customer_profile",le="1"} 1`,
+        `ovo_request_duration_seconds_bucket{endpoint="customer_profile",le="+Inf"} 2`,
+        `ovo_request_duration_seconds_count{endpoint="customer_profile"} 2`,
+        `ovo_linkage_total{step="auth_sent"} 1`,
+        `# TYPE ovo_request_duration_seconds histogram`,
+    }
+    for _, line := range expected {
+        if !strings.Contains(out, line+"\n") {
+            t.Errorf("Metrics should contain %s, got:\n%s", line, out)
+        }
+    }
+}
@@ -0,0 +1,37 @@
+package ovo
+
+import (
+    "net/http"
+    "net/http/httptest"
+)
+
+//Do : Call the function
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+    return f(req)
+}
+
+//Use : Append middlewares between the signed request and the http client, the first one registered is the outermost.
+//Not safe to call while requests are being sent
+func (client *Client) Use(middlewares ...Middleware) {
+    client.middlewares = append(client.middlewares, middlewares...)
+}
+
+//doer : Middlewares chain ending with the http client
+func (client *Client) doer() Doer {
+    var d Doer = client.getHTTPClient()
+    if client.httpHandler != nil {
+        d = DoerFunc(client.serveHandler)
+    }
+
+    for i := len(client.middlewares) - 1; i >= 0; i-- {
+        d = client.middlewares[i](d)
+    }
+    return d
+}
+
+//serveHandler : Answer request with httpHandler instead of OVO
+func (client *Client) serveHandler(req *http.Request) (*http.Response, error) {
+    w := httptest.NewRecorder()
+    client.httpHandler(w, req)
+    return w.Result(), nil
+}
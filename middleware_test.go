@@ -0,0 +1,66 @@
+package ovo
+
+import (
+    "io/ioutil"
+    "net/http"
+    "strings"
+    "testing"
+)
+
+func TestMiddleware(t *testing.T) {
+    var order []string
+    var header string
+
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        order = append(order, "ovo")
+        header = r.Header.Get("X-Request-Id")
+        w.Write([]byte(`{"status": 200}`))
+    }
+
+    trace := func(name string) Middleware {
+        return func(next Doer) Doer {
+            return DoerFunc(func(req *http.Request) (*http.Response, error) {
+                order = append(order, name)
+                return next.Do(req)
+            })
+        }
+    }
+    client.Use(trace("first"), trace("second"))
+    client.Use(func(next Doer) Doer {
+        return DoerFunc(func(req *http.Request) (*http.Response, error) {
+            req.Header.Set("X-Request-Id", "abc")
+            return next.Do(req)
+        })
+    })
+
+    if _, err := client.GetCustomerProfile("081234567890"); err != nil {
+        t.Fatal(err)
+    }
+    if strings.Join(order, ",") != "first,second,ovo" {
+        t.Errorf("Middlewares should run in registration order, got %v", order)
+    }
+    if header != "abc" {
+        t.Errorf("Middleware should be able to change the request")
+    }
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        t.Errorf("OVO should not be called")
+    }
+    client.Use(func(next Doer) Doer {
+        return DoerFunc(func(req *http.Request) (*http.Response, error) {
+            return &http.Response{
+                StatusCode: http.StatusOK,
+                Body:       ioutil.NopCloser(strings.NewReader(`{"status": 200, "data": {"fullname": "Budi"}}`)),
+            }, nil
+        })
+    })
+
+    data, err := client.GetCustomerProfile("081234567890")
+    if err != nil || !strings.Contains(string(data), "Budi") {
+        t.Errorf("Middleware response should be returned, got %s %v", data, err)
+    }
+}
@@ -0,0 +1,15 @@
+-- Audit of removed linkages, also used for the unlink cool-down of a phone
+CREATE TABLE IF NOT EXISTS customer_ovo_unlink (
+    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    customer_id BIGINT          NOT NULL,
+    ovo_id      VARCHAR(50)     NULL,
+    ovo_phone   VARCHAR(20)     NOT NULL,
+    ovo_auth_id VARCHAR(50)     NOT NULL DEFAULT '',
+    fg_verified TINYINT(1)      NOT NULL DEFAULT 0,
+    reason      VARCHAR(32)     NOT NULL,
+    source      VARCHAR(50)     NOT NULL DEFAULT '',
+    unlinked_at DATETIME        NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_customer_ovo_unlink_customer (customer_id),
+    KEY idx_customer_ovo_unlink_phone (ovo_phone, unlinked_at)
+);
@@ -0,0 +1,20 @@
+-- Every customer_ovo mutation with old and new values
+CREATE TABLE IF NOT EXISTS customer_ovo_history (
+    id              BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    customer_id     BIGINT          NOT NULL,
+    action          VARCHAR(16)     NOT NULL,
+    old_ovo_id      VARCHAR(50)     NOT NULL DEFAULT '',
+    new_ovo_id      VARCHAR(50)     NOT NULL DEFAULT '',
+    old_ovo_phone   VARCHAR(20)     NOT NULL DEFAULT '',
+    new_ovo_phone   VARCHAR(20)     NOT NULL DEFAULT '',
+    old_ovo_auth_id VARCHAR(50)     NOT NULL DEFAULT '',
+    new_ovo_auth_id VARCHAR(50)     NOT NULL DEFAULT '',
+    old_fg_verified TINYINT(1)      NOT NULL DEFAULT 0,
+    new_fg_verified TINYINT(1)      NOT NULL DEFAULT 0,
+    actor           VARCHAR(100)    NOT NULL DEFAULT '',
+    source          VARCHAR(50)     NOT NULL DEFAULT '',
+    reason          VARCHAR(50)     NOT NULL DEFAULT '',
+    created_at      DATETIME        NOT NULL,
+    PRIMARY KEY (id),
+    KEY idx_customer_ovo_history_customer (customer_id, created_at)
+);
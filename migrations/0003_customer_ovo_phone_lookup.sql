@@ -0,0 +1,9 @@
+-- Normalized phone (E.164) maintained by the sdk, phone based lookups use it instead of ovo_phone.
+-- Rows written before this migration have a NULL lookup until MatahariMall.BackfillPhoneLookup runs
+ALTER TABLE customer_ovo
+    ADD COLUMN ovo_phone_lookup VARCHAR(64) NULL AFTER ovo_phone,
+    ADD KEY idx_customer_ovo_phone_lookup (ovo_phone_lookup);
+
+ALTER TABLE customer_ovo_unlink
+    ADD COLUMN ovo_phone_lookup VARCHAR(64) NULL AFTER ovo_phone,
+    ADD KEY idx_customer_ovo_unlink_phone_lookup (ovo_phone_lookup, unlinked_at);
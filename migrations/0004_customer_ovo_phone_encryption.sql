@@ -0,0 +1,7 @@
+-- Room for phones encrypted by MatahariMall.PhoneCipher (enc:<key id>:<base64>),
+-- ovo_phone_lookup holds the 64 characters hex blind index once ReencryptPhones has run
+ALTER TABLE customer_ovo MODIFY ovo_phone VARCHAR(255) NOT NULL;
+ALTER TABLE customer_ovo_unlink MODIFY ovo_phone VARCHAR(255) NOT NULL;
+ALTER TABLE customer_ovo_history
+    MODIFY old_ovo_phone VARCHAR(255) NOT NULL DEFAULT '',
+    MODIFY new_ovo_phone VARCHAR(255) NOT NULL DEFAULT '';
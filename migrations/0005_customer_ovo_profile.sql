@@ -0,0 +1,9 @@
+-- OVO profile of verified linkages refreshed by MatahariMall.SyncProfiles.
+-- fg_disabled is set when OVO reports the loyalty account as disabled, IsOvoPaymentEnabled is then false
+ALTER TABLE customer_ovo
+    ADD COLUMN ovo_level VARCHAR(64) NULL,
+    ADD COLUMN ovo_fullname VARCHAR(255) NULL,
+    ADD COLUMN ovo_point_total BIGINT NULL,
+    ADD COLUMN fg_disabled TINYINT(1) NOT NULL DEFAULT 0,
+    ADD COLUMN last_synced_at DATETIME NULL,
+    ADD KEY idx_customer_ovo_last_synced_at (fg_verified, last_synced_at);
@@ -0,0 +1,14 @@
+-- New phone of a verified linkage moved by MatahariMall.RelinkCustomer, waiting for the customer to approve
+-- its authentication. The linkage in customer_ovo is replaced once approved
+CREATE TABLE IF NOT EXISTS customer_ovo_relink (
+    customer_id      BIGINT       NOT NULL,
+    ovo_phone        VARCHAR(255) NOT NULL,
+    ovo_phone_lookup VARCHAR(64)  NULL,
+    ovo_auth_id      VARCHAR(50)  NOT NULL,
+    reason           VARCHAR(32)  NOT NULL,
+    actor            VARCHAR(100) NOT NULL DEFAULT '',
+    source           VARCHAR(50)  NOT NULL DEFAULT '',
+    created_at       DATETIME     NOT NULL,
+    PRIMARY KEY (customer_id),
+    KEY idx_customer_ovo_relink_auth (ovo_auth_id)
+);
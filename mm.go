@@ -3,70 +3,88 @@ package ovo
 import (
     "database/sql"
     "encoding/json"
-    "errors"
-    "fmt"
-    "log"
     "net/http"
-    "regexp"
+    "sort"
     "strings"
     "time"
+
+    "github.com/kh411d/ovo/phone"
 )
 
 //GetMMsdk : Get Matahari Mall sdk
 func (client *Client) GetMMsdk(db *sql.DB) *MatahariMall {
     return &MatahariMall{
-        DB:  db,
-        API: client,
+        DB:             db,
+        API:            client,
+        UnlinkCooldown: DefaultUnlinkCooldown,
     }
 }
 
 func (c *MatahariMall) parsePhoneNumber(ovoReq *Request) error {
-    re := regexp.MustCompile(PhoneValidRegex)
-    x := re.MatchString(ovoReq.Phone)
-    if x {
-        ovoReq.Phone = strings.Replace(ovoReq.Phone, "+", "", 2)
-    } else {
-        return TErr("ovo_id_invalid", c.API.LocaleID)
-    }
-
-    if ovoReq.Phone[0:2] == "62" {
-        ovoReq.Phone = "0" + ovoReq.Phone[2:]
+    p, err := phone.National(ovoReq.Phone)
+    if err != nil {
+        return causeErr("ovo_id_invalid", c.API.LocaleID, err)
     }
+    ovoReq.Phone = p
 
     return nil
 }
 
-func (c *MatahariMall) getOvoInfoFromStorage(ovoReq *Request) error {
+//clone : Copy of the sdk without request state, safe to use from another goroutine
+func (c *MatahariMall) clone() *MatahariMall {
+    cp := *c
+    cp.OvoInfo = nil
+    cp.OvoReq = nil
+    cp.stored = nil
+    return &cp
+}
+
+func (c *MatahariMall) queryCustomerOvo(where string, arg interface{}) (CustomerOvo, error) {
     cOvo := CustomerOvo{}
 
-    var ovoID sql.NullString
+    var ovoID, source sql.NullString
 
     q := `SELECT customer_id,
                    ovo_id,
                    ovo_phone,
                    ovo_auth_id,
-                   fg_verified
+                   fg_verified,
+                   source
             FROM customer_ovo
-            WHERE customer_id=?`
+            WHERE ` + where
 
-    err := c.DB.QueryRow(q, ovoReq.CustomerID).Scan(
+    end := c.sqlSpan("SELECT", "customer_ovo")
+    err := c.DB.QueryRow(q, arg).Scan(
         &cOvo.CustomerID,
         &ovoID,
         &cOvo.OvoPhone,
         &cOvo.OvoAuthID,
         &cOvo.FgVerified,
+        &source,
     )
+    end(err)
 
     if ovoID.Valid {
         cOvo.OvoID = ovoID.String
     }
+    cOvo.Source = source.String
+    if err == nil {
+        cOvo.OvoPhone, err = c.openPhone(cOvo.OvoPhone)
+    }
+
+    return cOvo, err
+}
+
+func (c *MatahariMall) getOvoInfoFromStorage(ovoReq *Request) error {
+    cOvo, err := c.queryCustomerOvo("customer_id=?", ovoReq.CustomerID)
 
     c.OvoInfo = &cOvo
+    c.stored = snapshot(&cOvo)
     if err != nil {
         if err == sql.ErrNoRows {
             return nil
         }
-        return err
+        return wrapErr(err, ErrCategoryTransport)
     }
 
     return nil
@@ -74,18 +92,24 @@ func (c *MatahariMall) getOvoInfoFromStorage(ovoReq *Request) error {
 }
 
 func (c *MatahariMall) isPhoneNumberAlreadyLinkage(ovoReq *Request) (bool, error) {
+    if err := c.parsePhoneNumber(ovoReq); err != nil {
+        return false, err
+    }
+
     var s sql.NullString
     q := `SELECT ovo_phone
             FROM customer_ovo
-            WHERE ovo_phone = ?
+            WHERE ovo_phone_lookup = ?
               AND ovo_id != '' LIMIT 1`
 
-    err := c.DB.QueryRow(q, ovoReq.Phone).Scan(&s)
+    end := c.sqlSpan("SELECT", "customer_ovo")
+    err := c.DB.QueryRow(q, c.phoneLookup(ovoReq.Phone)).Scan(&s)
+    end(err)
     if err != nil {
         if err == sql.ErrNoRows {
             return false, nil
         }
-        return false, err
+        return false, wrapErr(err, ErrCategoryTransport)
     }
 
     if !s.Valid {
@@ -104,12 +128,14 @@ func (c *MatahariMall) IsLinkageVerified(customerID int64) (bool, string, error)
            WHERE customer_id = ?
              AND fg_verified = 1`
 
+    end := c.sqlSpan("SELECT", "customer_ovo")
     err := c.DB.QueryRow(q, customerID).Scan(&s)
+    end(err)
     if err != nil {
         if err == sql.ErrNoRows {
             return false, ovoID, nil
         }
-        return false, ovoID, err
+        return false, ovoID, wrapErr(err, ErrCategoryTransport)
     }
 
     if !s.Valid {
@@ -124,20 +150,26 @@ func (c *MatahariMall) IsLinkageVerified(customerID int64) (bool, string, error)
 }
 
 //IsLinkageVerifiedByPhone : Check if customer linkage is already verified by phone
-func (c *MatahariMall) IsLinkageVerifiedByPhone(phone string) (bool, string, error) {
+func (c *MatahariMall) IsLinkageVerifiedByPhone(ovoPhone string) (bool, string, error) {
     var s sql.NullString
     var ovoID string
+
+    if !phone.Valid(ovoPhone) {
+        return false, ovoID, causeErr("ovo_id_invalid", c.API.LocaleID, phone.ErrNotMobile)
+    }
     q := `SELECT ovo_id
             FROM customer_ovo
-           WHERE ovo_phone = ?
+           WHERE ovo_phone_lookup = ?
              AND fg_verified = 1`
 
-    err := c.DB.QueryRow(q, phone).Scan(&s)
+    end := c.sqlSpan("SELECT", "customer_ovo")
+    err := c.DB.QueryRow(q, c.phoneLookup(ovoPhone)).Scan(&s)
+    end(err)
     if err != nil {
         if err == sql.ErrNoRows {
             return false, ovoID, nil
         }
-        return false, ovoID, err
+        return false, ovoID, wrapErr(err, ErrCategoryTransport)
     }
 
     if !s.Valid {
@@ -151,15 +183,17 @@ func (c *MatahariMall) IsLinkageVerifiedByPhone(phone string) (bool, string, err
     return true, ovoID, nil
 }
 
-func (c *MatahariMall) getCustomerOvoByPhone(phone string) (int64, int, error) {
+func (c *MatahariMall) getCustomerOvoByPhone(ovoPhone string) (int64, int, error) {
     var customerID sql.NullInt64
     var fgVerified sql.NullInt64
 
-    q := `SELECT customer_id, fg_verified FROM customer_ovo WHERE ovo_phone = ? LIMIT 1`
+    q := `SELECT customer_id, fg_verified FROM customer_ovo WHERE ovo_phone_lookup = ? LIMIT 1`
 
-    err := c.DB.QueryRow(q, phone).Scan(&customerID, &fgVerified)
+    end := c.sqlSpan("SELECT", "customer_ovo")
+    err := c.DB.QueryRow(q, c.phoneLookup(ovoPhone)).Scan(&customerID, &fgVerified)
+    end(err)
     if err != nil {
-        return 0, 0, err
+        return 0, 0, wrapErr(err, ErrCategoryTransport)
     }
     if !customerID.Valid || !fgVerified.Valid {
         return 0, 0, TErr("ovo_customer_unidentified", c.API.LocaleID)
@@ -173,11 +207,10 @@ func (c *MatahariMall) getCustomerOvoByPhone(phone string) (int64, int, error) {
 
 func (c *MatahariMall) validateOvoID(ovoReq *Request) error {
     var err error
-    /* Not to validate phone number
-       err = c.parsePhoneNumber(ovoReq)
-       if err != nil {
-           return err
-       }*/
+    err = c.parsePhoneNumber(ovoReq)
+    if err != nil {
+        return err
+    }
 
     err = c.getOvoInfoFromStorage(ovoReq)
     if err != nil {
@@ -199,15 +232,20 @@ func (c *MatahariMall) ValidateOvoIDAndAuthenticateToOvo(ovoReq *Request) error
     var err error
 
     c.OvoReq = ovoReq
+    if ovoReq.Reason == "" {
+        ovoReq.Reason = ReasonAuthenticationSent
+    }
 
     err = c.validateOvoID(ovoReq)
     if err != nil {
         return err
     }
+    before := snapshot(c.OvoInfo)
 
     cuid, fgVerified, _ := c.getCustomerOvoByPhone(c.OvoReq.Phone)
     //If existed customer by phone and customer doesn't match then stop process
     if cuid > 0 && cuid != c.OvoReq.CustomerID {
+        c.funnel(FunnelIDUsed)
         return TErr("ovo_id_used", c.API.LocaleID)
     }
 
@@ -216,6 +254,11 @@ func (c *MatahariMall) ValidateOvoIDAndAuthenticateToOvo(ovoReq *Request) error
         return TErr("ovo_already_verified", c.API.LocaleID)
     }
 
+    err = c.checkUnlinkCooldown(ovoReq)
+    if err != nil {
+        return err
+    }
+
     err = c.doCustomerAuthenticationAtOvo(ovoReq)
     if err != nil {
         return err
@@ -226,10 +269,28 @@ func (c *MatahariMall) ValidateOvoIDAndAuthenticateToOvo(ovoReq *Request) error
         return err
     }
 
+    after := snapshot(c.OvoInfo)
+    c.funnel(FunnelAuthSent)
+    c.publish(LinkageSubscriber.OnAuthenticationSent, before, after)
+    c.linkageChanged(before, after)
+
     return nil
 }
 
 func (c *MatahariMall) doCustomerAuthenticationAtOvo(ovoReq *Request) error {
+    authID, err := c.sendAuthentication(ovoReq)
+    if err != nil {
+        return err
+    }
+
+    c.OvoInfo.OvoAuthID = authID
+    c.OvoInfo.FgVerified = 0
+    c.OvoInfo.Source = c.API.AppID
+    return nil
+}
+
+//sendAuthentication : Push the security code screen to the ovoReq phone, return the authentication id
+func (c *MatahariMall) sendAuthentication(ovoReq *Request) (string, error) {
 
     params := Params{
         "merchant_id": c.API.MerchantID,
@@ -238,29 +299,26 @@ func (c *MatahariMall) doCustomerAuthenticationAtOvo(ovoReq *Request) error {
 
     data, err := c.API.CustomerAuthentication(params)
     if err != nil {
-        return err
+        return "", err
     }
 
     var r Response
     r, err = c.API.getResponse(data)
     if err != nil {
-        return err
+        return "", err
     }
 
     if r.Status == http.StatusCreated {
         if r.Code == sendingAuthentication {
             ovoReq.AuthID = r.Data.AuthenticationID
             ovoReq.AuthStatus = r.Code
-            c.OvoInfo.OvoAuthID = r.Data.AuthenticationID
-            c.OvoInfo.FgVerified = 0
-        } else {
-            return &CustomError{r.Code, r.Message}
+            c.log().Info("ovo authentication sent", "endpoint", "customer_authentication", "customer_id", ovoReq.CustomerID, "code", r.Code)
+            return r.Data.AuthenticationID, nil
         }
-    } else {
-        return &CustomError{r.Code, r.Message}
     }
 
-    return nil
+    c.log().Warn("ovo authentication not sent", "endpoint", "customer_authentication", "customer_id", ovoReq.CustomerID, "status", r.Status, "code", r.Code, "message", r.Message)
+    return "", responseErr(r)
 }
 
 func (c *MatahariMall) saveToDatabase() error {
@@ -277,23 +335,42 @@ func (c *MatahariMall) saveToDatabase() error {
         newLinkage = true
         ovoInfo.CustomerID = c.OvoReq.CustomerID
         ovoInfo.OvoPhone = c.OvoReq.Phone
+        if ovoInfo.Source == "" {
+            ovoInfo.Source = c.API.AppID
+        }
+    }
+
+    sealedPhone, err := c.sealPhone(c.OvoReq.Phone)
+    if err != nil {
+        return err
+    }
+
+    tx, err := c.DB.Begin()
+    if err != nil {
+        return wrapErr(err, ErrCategoryTransport)
     }
 
+    action := HistoryUpdate
     if newLinkage {
+        action = HistoryInsert
         sqlInsert := `INSERT INTO
                         customer_ovo(
                             customer_id,
                             ovo_phone,
+                            ovo_phone_lookup,
                             ovo_auth_id,
                             fg_verified,
                             created_at,
                             updated_at,
                             source
                         )
-                      VALUES (?, ?, ?, 0, NOW(), NOW(), ?)`
-        _, errDBInsert := c.DB.Exec(sqlInsert, ovoInfo.CustomerID, ovoInfo.OvoPhone, ovoInfo.OvoAuthID, c.API.AppID)
+                      VALUES (?, ?, ?, ?, 0, NOW(), NOW(), ?)`
+        end := c.sqlSpan("INSERT", "customer_ovo")
+        _, errDBInsert := tx.Exec(sqlInsert, ovoInfo.CustomerID, sealedPhone, nullString(c.phoneLookup(ovoInfo.OvoPhone)), ovoInfo.OvoAuthID, ovoInfo.Source)
+        end(errDBInsert)
         if errDBInsert != nil {
-            return errDBInsert
+            tx.Rollback()
+            return wrapErr(errDBInsert, ErrCategoryTransport)
         }
     } else {
         var ovoID sql.NullString
@@ -303,28 +380,57 @@ func (c *MatahariMall) saveToDatabase() error {
             ovoID.Valid = true
         }
         toUpdate := map[string]interface{}{
-            "ovo_id":      ovoID,
-            "ovo_auth_id": ovoInfo.OvoAuthID,
-            "fg_verified": ovoInfo.FgVerified,
+            "ovo_id":           ovoID,
+            "ovo_phone_lookup": nullString(c.phoneLookup(c.OvoReq.Phone)),
+            "ovo_auth_id":      ovoInfo.OvoAuthID,
+            "fg_verified":      ovoInfo.FgVerified,
+        }
+        //Only a merchant sending the authentication changes the source, status checks and callbacks keep it
+        if c.stored != nil && ovoInfo.Source != c.stored.Source {
+            toUpdate["source"] = ovoInfo.Source
         }
-        fmt.Println(c.OvoReq)
         if ovoInfo.OvoPhone != c.OvoReq.Phone {
-            fmt.Println("ga sama woi")
-            toUpdate["ovo_phone"] = c.OvoReq.Phone
+            c.log().Info("ovo phone changed", "customer_id", ovoInfo.CustomerID, "phone", c.OvoReq.Phone)
+            toUpdate["ovo_phone"] = sealedPhone
         }
 
-        return c.updateCustomerOVO(ovoInfo.CustomerID, toUpdate)
+        err = c.updateCustomerOVO(tx, ovoInfo.CustomerID, toUpdate)
+        if err != nil {
+            tx.Rollback()
+            return err
+        }
+        ovoInfo.OvoPhone = c.OvoReq.Phone
     }
+
+    after := snapshot(ovoInfo)
+    err = c.addHistory(tx, action, c.stored, after, c.OvoReq.Actor, c.OvoReq.Reason)
+    if err != nil {
+        tx.Rollback()
+        return err
+    }
+
+    if err = tx.Commit(); err != nil {
+        return wrapErr(err, ErrCategoryTransport)
+    }
+    c.invalidateProfiles(c.stored, after)
+    c.stored = after
+
     return nil
 }
 
-func (c *MatahariMall) updateCustomerOVO(customerID interface{}, toUpdate map[string]interface{}) error {
+func (c *MatahariMall) updateCustomerOVO(db execer, customerID interface{}, toUpdate map[string]interface{}) error {
     sqlUpdate := `UPDATE customer_ovo SET updated_at = NOW() `
     var setStr []string
     var vals []interface{}
-    for k, v := range toUpdate {
+    //Columns in name order, the statement stays the same for the same columns
+    columns := make([]string, 0, len(toUpdate))
+    for k := range toUpdate {
+        columns = append(columns, k)
+    }
+    sort.Strings(columns)
+    for _, k := range columns {
         setStr = append(setStr, k+" = ?")
-        vals = append(vals, v)
+        vals = append(vals, toUpdate[k])
     }
     if len(setStr) > 0 {
         sqlUpdate = sqlUpdate + "," + strings.Join(setStr, ",") + " "
@@ -332,13 +438,16 @@ func (c *MatahariMall) updateCustomerOVO(customerID interface{}, toUpdate map[st
 
     vals = append(vals, customerID)
     sqlUpdate += " WHERE customer_id = ?"
-    res, err := c.DB.Exec(sqlUpdate, vals...)
+    end := c.sqlSpan("UPDATE", "customer_ovo")
+    res, err := db.Exec(sqlUpdate, vals...)
+    end(err)
 
     if err != nil {
         if strings.Contains(err.Error(), "1062") {
-            return TErr("ovo_id_used", c.API.LocaleID)
+            c.funnel(FunnelIDUsed)
+            return causeErr("ovo_id_used", c.API.LocaleID, err)
         }
-        return err
+        return wrapErr(err, ErrCategoryTransport)
     }
 
     if rowAffected, _ := res.RowsAffected(); rowAffected == 0 {
@@ -352,16 +461,18 @@ func (c *MatahariMall) updateCustomerOVO(customerID interface{}, toUpdate map[st
 func (c *MatahariMall) CheckOvoStatus(customerID int64) (*CustomerOvo, error) {
     ovoReq := &Request{
         CustomerID: customerID,
+        Reason:     ReasonStatusCheck,
     }
     c.OvoReq = ovoReq
     err := c.getOvoInfoFromStorage(ovoReq)
     if err != nil {
-        return nil, TErr("ovo_unknown_info", c.API.LocaleID)
+        return nil, causeErr("ovo_unknown_info", c.API.LocaleID, err)
     }
     c.OvoReq.Phone = c.OvoInfo.OvoPhone
     if c.OvoInfo.CustomerID == 0 {
         return nil, TErr("ovo_not_authenticated", c.API.LocaleID)
     } else if c.OvoInfo.FgVerified <= 0 {
+        before := snapshot(c.OvoInfo)
         err = c.getCustomerAuthenticationStatusAtOvo()
         if err != nil {
             return nil, err
@@ -371,6 +482,7 @@ func (c *MatahariMall) CheckOvoStatus(customerID int64) (*CustomerOvo, error) {
         if err != nil {
             return nil, err
         }
+        c.linkageChanged(before, snapshot(c.OvoInfo))
     }
 
     return c.OvoInfo, nil
@@ -378,12 +490,7 @@ func (c *MatahariMall) CheckOvoStatus(customerID int64) (*CustomerOvo, error) {
 }
 
 func (c *MatahariMall) getCustomerAuthenticationStatusAtOvo() error {
-    data, err := c.API.CheckCustomerAuthenticationStatus(c.OvoInfo.OvoAuthID)
-    if err != nil {
-        return err
-    }
-    var r Response
-    r, err = c.API.getResponse(data)
+    r, err := c.authenticationStatus(c.OvoInfo.OvoAuthID)
     if err != nil {
         return err
     }
@@ -392,11 +499,17 @@ func (c *MatahariMall) getCustomerAuthenticationStatusAtOvo() error {
         if r.Code == Authenticated {
             c.OvoInfo.OvoID = r.Data.LoyaltyID
             c.OvoInfo.FgVerified = 1
+            c.log().Info("ovo linkage verified", "endpoint", "customer_authentication_status", "customer_id", c.OvoInfo.CustomerID, "code", r.Code)
             return nil
         }
     }
 
+    c.log().Info("ovo linkage not verified", "endpoint", "customer_authentication_status", "customer_id", c.OvoInfo.CustomerID, "status", r.Status, "code", r.Code)
     if r.Code == Unauthenticated || r.Code == AuthIDNotFound || r.Code == CustomerNotFound {
+        if authenticationRejected(r) {
+            c.funnel(FunnelRejected)
+            c.publish(LinkageSubscriber.OnLinkageRejected, snapshot(c.OvoInfo), snapshot(c.OvoInfo))
+        }
         return TErr("ovo_retry_verification", c.API.LocaleID)
     }
 
@@ -404,30 +517,60 @@ func (c *MatahariMall) getCustomerAuthenticationStatusAtOvo() error {
 
 }
 
+//authenticationStatus : Status of the authentication at OVO, an unknown authentication or customer is a response too
+func (c *MatahariMall) authenticationStatus(authID string) (Response, error) {
+    data, err := c.API.CheckCustomerAuthenticationStatus(authID)
+    switch code := GetErrCode(err); {
+    case err == nil:
+        return c.API.getResponse(data)
+    case code == AuthIDNotFound || code == CustomerNotFound:
+        //Unknown authentication or customer is answered with a not found status
+        return Response{Code: code}, nil
+    }
+    return Response{}, err
+}
+
+//authenticationRejected : Authentication ended without approval. Unauthenticated is answered while waiting for the customer
+//and once the customer rejected it, only the message tells them apart
+func authenticationRejected(r Response) bool {
+    switch r.Code {
+    case AuthIDNotFound, CustomerNotFound:
+        return true
+    case Unauthenticated:
+        return strings.Contains(strings.ToLower(r.Message), "reject")
+    }
+    return false
+}
+
 //CalculateHyperOvoPoint : Calculate Ovo Point for Hyper only
 func (c *MatahariMall) CalculateHyperOvoPoint(ovoID string, param Params) error {
 
     data, err := c.API.CalculatePoints(ovoID, param)
     if err != nil {
+        //The invoice was already calculated, a retried calculation is done
+        if GetErrCode(err) == DuplicateMerchantInvoice {
+            c.log().Info("ovo point already calculated", "endpoint", "calculate_points", "code", DuplicateMerchantInvoice)
+            return nil
+        }
         return err
     }
     var r Response
     r, err = c.API.getResponse(data)
     if err != nil {
-        fmt.Printf("Err CalculateHyperOvoPoint %v \n", err)
+        c.log().Error("ovo calculate point response invalid", "endpoint", "calculate_points", "error", err)
         return err
     }
 
     if r.Status == http.StatusOK {
-        log.Printf("%#v", r.Data)
+        c.log().Info("ovo point calculated", "endpoint", "calculate_points", "point_earned", r.Data.PointEarned, "point_total", r.Data.PointTotal)
         return nil
     }
-    fmt.Printf("Response CalculateHyperOvoPoint %v \n", r)
+    c.log().Warn("ovo calculate point failed", "endpoint", "calculate_points", "status", r.Status, "code", r.Code, "message", r.Message)
     if r.Code != Success {
         if r.Code == DuplicateMerchantInvoice {
             return nil
         }
-        return errors.New(r.Message)
+        return responseErr(r)
     }
 
     return err
@@ -438,7 +581,7 @@ func (c *MatahariMall) CalculateHyperOvoPoint(ovoID string, param Params) error
 func (c *MatahariMall) AddOvoPointHistory(customerID, orderID int64, soNumber, pointType string, payload Params, flags ...map[string]interface{}) error {
     jsonPayload, err := json.Marshal(payload)
     if err != nil {
-        return err
+        return wrapErr(err, ErrCategoryValidation)
     }
 
     var fgFailed interface{}
@@ -461,9 +604,11 @@ func (c *MatahariMall) AddOvoPointHistory(customerID, orderID int64, soNumber, p
                             fg_failed
                         )
                       VALUES (?, ?, ?, ?, ?, ?)`
+    end := c.sqlSpan("INSERT", "ovo_points")
     _, errDBInsert := c.DB.Exec(sqlInsert, customerID, orderID, soNumber, pointType, jsonPayload, fgFailed)
+    end(errDBInsert)
     if errDBInsert != nil {
-        return errDBInsert
+        return wrapErr(errDBInsert, ErrCategoryTransport)
     }
 
     return nil
@@ -53,7 +53,9 @@ func TestSaveToDatabaseUpdate(t *testing.T) {
     }
     defer db.Close()
 
+    mock.ExpectBegin()
     mock.ExpectExec(`UPDATE customer_ovo`).WillReturnResult(sqlmock.NewResult(0, 0))
+    mock.ExpectRollback()
 
     client := new(Client)
     client.LocaleID = "en"
@@ -61,13 +63,13 @@ func TestSaveToDatabaseUpdate(t *testing.T) {
     mmsdk.OvoInfo = &CustomerOvo{
         CustomerID: 1234,
         OvoID:      "",
-        OvoPhone:   "08282828",
+        OvoPhone:   "081282828282",
         OvoAuthID:  "234",
         FgVerified: 0,
     }
     mmsdk.OvoReq = &Request{
         CustomerID: 1234,
-        Phone:      "08282828",
+        Phone:      "081282828282",
     }
 
     err = mmsdk.saveToDatabase()
@@ -83,7 +85,10 @@ func TestSaveToDatabaseInsert(t *testing.T) {
     }
     defer db.Close()
 
+    mock.ExpectBegin()
     mock.ExpectExec(`INSERT INTO customer_ovo`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
 
     client := new(Client)
     client.LocaleID = "en"
@@ -94,7 +99,7 @@ func TestSaveToDatabaseInsert(t *testing.T) {
         FgVerified: 0,
     }
     mmsdk.OvoReq = &Request{
-        Phone: "08282828",
+        Phone: "081282828282",
     }
 
     err = mmsdk.saveToDatabase()
@@ -123,7 +128,7 @@ func TestParsePhoneNumberInvalid(t *testing.T) {
     mmsdk := client.GetMMsdk(nil)
 
     ovoReq := &Request{
-        Phone: "818223456",
+        Phone: "abc0812345",
     }
     err := mmsdk.parsePhoneNumber(ovoReq)
     if err == nil {
@@ -185,12 +190,12 @@ func TestValidateOvoID(t *testing.T) {
     }
     defer db.Close()
 
-    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified"}).AddRow(12345, "6789", "08080808", "123", 1)
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, "6789", "081208080808", "123", 1, "hypermart")
     mock.ExpectQuery(`SELECT customer_id`).WillReturnRows(rows)
 
     ovoReq := &Request{
         CustomerID: 12345,
-        Phone:      "08080808",
+        Phone:      "081208080808",
     }
 
     client := new(Client)
@@ -203,7 +208,7 @@ func TestValidateOvoID(t *testing.T) {
     }
 
     if erro != nil && erro.Error() != TErr("ovo_already_verified", client.LocaleID).Error() {
-        t.Errorf("This should return Err: " + TErr("ovo_already_verified", client.LocaleID).Error())
+        t.Errorf("This should return Err: %s", TErr("ovo_already_verified", client.LocaleID))
     }
 }
 
@@ -214,12 +219,12 @@ func TestValidateOvoIDChange(t *testing.T) {
     }
     defer db.Close()
 
-    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified"}).AddRow(12345, "6789", "08080808", "123", 1)
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, "6789", "081208080808", "123", 1, "hypermart")
     mock.ExpectQuery(`SELECT customer_id`).WillReturnRows(rows)
 
     ovoReq := &Request{
         CustomerID: 12345,
-        Phone:      "0909090909",
+        Phone:      "081909090909",
     }
 
     client := new(Client)
@@ -232,7 +237,7 @@ func TestValidateOvoIDChange(t *testing.T) {
     }
 
     if erro != nil && erro.Error() != TErr("ovo_change_verified", client.LocaleID).Error() {
-        t.Errorf("This should return Err: " + TErr("ovo_change_verified", client.LocaleID).Error())
+        t.Errorf("This should return Err: %s", TErr("ovo_change_verified", client.LocaleID))
     }
 }
 
@@ -243,15 +248,15 @@ func TestValidateOvoIDAndAuthenticateToOvoLinkage(t *testing.T) {
     }
     defer db.Close()
 
-    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified"}).AddRow(12345, "6789", "08080808", "123", 1)
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, "6789", "081208080808", "123", 1, "hypermart")
     mock.ExpectQuery(`SELECT customer_id`).WillReturnRows(rows)
 
-    rowsOvophone := sqlmock.NewRows([]string{"ovo_phone"}).AddRow("08080808")
+    rowsOvophone := sqlmock.NewRows([]string{"ovo_phone"}).AddRow("081208080808")
     mock.ExpectQuery(`SELECT ovo_phone FROM customer_ovo`).WillReturnRows(rowsOvophone)
 
     ovoReq := &Request{
         CustomerID: 12345,
-        Phone:      "08080808",
+        Phone:      "081208080808",
     }
 
     client := new(Client)
@@ -261,7 +266,7 @@ func TestValidateOvoIDAndAuthenticateToOvoLinkage(t *testing.T) {
     err = mmsdk.ValidateOvoIDAndAuthenticateToOvo(ovoReq)
 
     if err != nil && err.Error() != TErr("ovo_already_verified", client.LocaleID).Error() {
-        t.Errorf("This should return Err: " + TErr("ovo_already_verified", client.LocaleID).Error())
+        t.Errorf("This should return Err: %s", TErr("ovo_already_verified", client.LocaleID))
     }
 }
 
@@ -272,15 +277,19 @@ func TestValidateOvoIDAndAuthenticateToOvoSuccessUpdate(t *testing.T) {
     }
     defer db.Close()
 
-    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified"}).AddRow(12345, "6789", "08080808", "123", 0)
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, "6789", "081208080808", "123", 0, "hypermart")
     mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnRows(rows)
 
     mock.ExpectQuery(`SELECT customer_id, fg_verified FROM customer_ovo`).WillReturnRows(sqlmock.NewRows([]string{"customer_id", "fg_verified"}).AddRow(12345, 0))
+    mock.ExpectQuery(`SELECT customer_id FROM customer_ovo_unlink`).WillReturnError(sql.ErrNoRows)
+    mock.ExpectBegin()
     mock.ExpectExec(`UPDATE customer_ovo`).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
 
     ovoReq := &Request{
         CustomerID: 12345,
-        Phone:      "08080808",
+        Phone:      "081208080808",
     }
 
     client := new(Client)
@@ -294,6 +303,7 @@ func TestValidateOvoIDAndAuthenticateToOvoSuccessUpdate(t *testing.T) {
                     "message": "Success",
                     "code": 1
                 }`
+        w.WriteHeader(http.StatusCreated)
         w.Write([]byte(data))
     }
     mmsdk := client.GetMMsdk(db)
@@ -315,11 +325,15 @@ func TestValidateOvoIDAndAuthenticateToOvoSuccessInsert(t *testing.T) {
     mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnError(sql.ErrNoRows)
 
     mock.ExpectQuery(`SELECT customer_id, fg_verified FROM customer_ovo`).WillReturnError(sql.ErrNoRows)
+    mock.ExpectQuery(`SELECT customer_id FROM customer_ovo_unlink`).WillReturnError(sql.ErrNoRows)
+    mock.ExpectBegin()
     mock.ExpectExec(`INSERT`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
 
     ovoReq := &Request{
         CustomerID: 12345,
-        Phone:      "08080808",
+        Phone:      "081208080808",
     }
 
     client := new(Client)
@@ -333,6 +347,7 @@ func TestValidateOvoIDAndAuthenticateToOvoSuccessInsert(t *testing.T) {
                     "message": "Success",
                     "code": 1
                 }`
+        w.WriteHeader(http.StatusCreated)
         w.Write([]byte(data))
     }
     mmsdk := client.GetMMsdk(db)
@@ -362,7 +377,7 @@ func TestCheckOvoStatusUnauthenticated(t *testing.T) {
         t.Errorf("This should error upon not authenticated")
     }
     if err != nil && err.Error() != TErr("ovo_not_authenticated", client.LocaleID).Error() {
-        t.Errorf("This should return Err: " + TErr("ovo_not_authenticated", client.LocaleID).Error())
+        t.Errorf("This should return Err: %s", TErr("ovo_not_authenticated", client.LocaleID))
     }
 }
 
@@ -373,10 +388,13 @@ func TestCheckOvoStatusSuccess(t *testing.T) {
     }
     defer db.Close()
 
-    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified"}).AddRow(12345, "6789", "08080808", "123", 0)
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, "6789", "081208080808", "123", 0, "hypermart")
     mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnRows(rows)
 
+    mock.ExpectBegin()
     mock.ExpectExec(`UPDATE customer_ovo`).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
 
     client := new(Client)
     client.LocaleID = "en"
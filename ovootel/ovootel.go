@@ -0,0 +1,89 @@
+//Package ovootel : OpenTelemetry adapter for ovo.Tracer
+package ovootel
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+
+    "github.com/kh411d/ovo"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/codes"
+    "go.opentelemetry.io/otel/propagation"
+    "go.opentelemetry.io/otel/trace"
+)
+
+//InstrumentationName : Name of the OpenTelemetry tracer
+const InstrumentationName = "github.com/kh411d/ovo"
+
+//Tracer : ovo.Tracer starting OpenTelemetry spans
+type Tracer struct {
+    tracer     trace.Tracer
+    propagator propagation.TextMapPropagator
+}
+
+type span struct {
+    span trace.Span
+}
+
+//New : Constructor for Tracer, propagator writes the trace headers sent to OVO (W3C trace context when nil)
+func New(provider trace.TracerProvider, propagator propagation.TextMapPropagator) *Tracer {
+    if propagator == nil {
+        propagator = propagation.TraceContext{}
+    }
+    return &Tracer{
+        tracer:     provider.Tracer(InstrumentationName),
+        propagator: propagator,
+    }
+}
+
+//Start : Start client span as a child of the span in ctx
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...ovo.Attribute) (context.Context, ovo.Span) {
+    ctx, s := t.tracer.Start(ctx, name,
+        trace.WithSpanKind(trace.SpanKindClient),
+        trace.WithAttributes(convert(attrs)...),
+    )
+    return ctx, &span{s}
+}
+
+//Inject : Write the span context of ctx into header
+func (t *Tracer) Inject(ctx context.Context, header http.Header) {
+    t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+//SetAttributes : Set span attributes
+func (s *span) SetAttributes(attrs ...ovo.Attribute) {
+    s.span.SetAttributes(convert(attrs)...)
+}
+
+//RecordError : Record err and set the span status to error
+func (s *span) RecordError(err error) {
+    s.span.RecordError(err)
+    s.span.SetStatus(codes.Error, err.Error())
+}
+
+//End : End span
+func (s *span) End() {
+    s.span.End()
+}
+
+func convert(attrs []ovo.Attribute) []attribute.KeyValue {
+    kvs := make([]attribute.KeyValue, 0, len(attrs))
+    for _, a := range attrs {
+        switch v := a.Value.(type) {
+        case string:
+            kvs = append(kvs, attribute.String(a.Key, v))
+        case int:
+            kvs = append(kvs, attribute.Int(a.Key, v))
+        case int64:
+            kvs = append(kvs, attribute.Int64(a.Key, v))
+        case bool:
+            kvs = append(kvs, attribute.Bool(a.Key, v))
+        case float64:
+            kvs = append(kvs, attribute.Float64(a.Key, v))
+        default:
+            kvs = append(kvs, attribute.String(a.Key, fmt.Sprint(v)))
+        }
+    }
+    return kvs
+}
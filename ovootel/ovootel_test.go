@@ -0,0 +1,47 @@
+package ovootel_test
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/kh411d/ovo"
+    "github.com/kh411d/ovo/ovootel"
+    "go.opentelemetry.io/otel/codes"
+    sdktrace "go.opentelemetry.io/otel/sdk/trace"
+    "go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+    var traceparent string
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        traceparent = r.Header.Get("traceparent")
+        w.WriteHeader(http.StatusServiceUnavailable)
+    }))
+    defer srv.Close()
+
+    rec := tracetest.NewSpanRecorder()
+    provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+    ctx, parent := provider.Tracer("test").Start(context.Background(), "checkout")
+    client := ovo.New(srv.URL, "secret", "hypermart", "1")
+    client.SetTracer(ovootel.New(provider, nil))
+    client.WithContext(ctx).GetCustomerProfile("081234567890")
+    parent.End()
+
+    spans := rec.Ended()
+    if len(spans) != 2 {
+        t.Fatalf("This should record the OVO call and its parent, got %d spans", len(spans))
+    }
+    s := spans[0]
+    if s.Name() != "ovo.customer_profile" || s.Parent().SpanID() != parent.SpanContext().SpanID() {
+        t.Errorf("OVO call should be a child of checkout, got %s", s.Name())
+    }
+    if s.Status().Code != codes.Error {
+        t.Errorf("503 should set the error status")
+    }
+    if traceparent == "" || traceparent[36:52] != s.SpanContext().SpanID().String() {
+        t.Errorf("traceparent should carry the OVO call span, got %q", traceparent)
+    }
+}
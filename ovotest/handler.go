@@ -0,0 +1,330 @@
+package ovotest
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/kh411d/ovo"
+)
+
+//response : OVO api response envelope
+type response struct {
+    Status  int               `json:"status"`
+    Data    *ovo.ResponseData `json:"data,omitempty"`
+    Message string            `json:"message"`
+    Code    int               `json:"code"`
+}
+
+//ServeHTTP : Serve OVO loyalty-back api
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    h.mu.Lock()
+    latency := h.latency
+    var failure int
+    if len(h.failures) > 0 {
+        failure = h.failures[0]
+        h.failures = h.failures[1:]
+    }
+    h.mu.Unlock()
+
+    if latency > 0 {
+        select {
+        case <-time.After(latency):
+        case <-r.Context().Done():
+            return
+        }
+    }
+
+    if failure > 0 {
+        writeJSON(w, response{Status: failure, Message: http.StatusText(failure)})
+        return
+    }
+
+    if !h.authorized(r) {
+        writeJSON(w, response{Status: http.StatusUnauthorized, Message: "Unauthorized"})
+        return
+    }
+
+    name, vars := h.match(r.Method, r.URL.Path)
+    if name == "" {
+        writeJSON(w, response{Status: http.StatusNotFound, Message: "Not Found"})
+        return
+    }
+
+    r.ParseForm()
+
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    h.calls[name]++
+
+    var res response
+    switch name {
+    case "customer_profile":
+        res = h.customerProfile(h.findCustomer(vars[0]))
+    case "customer_profile_qr":
+        res = h.customerProfile(h.findCustomer(h.terminals[vars[0]+"/"+vars[1]+"/"+vars[2]]))
+    case "customer_linkage":
+        res = h.customerLinkage(vars[0], r)
+    case "calculate_points":
+        res = h.calculatePoints(vars[0], r)
+    case "customer_authentication":
+        res = h.customerAuthentication(r)
+    case "customer_authentication_status":
+        res = h.customerAuthenticationStatus(vars[0])
+    case "pushtopay_transaction":
+        res = h.createTransaction(vars[0], r)
+    case "pushtopay_transaction_status":
+        res = h.transactionStatus(vars[0], vars[1])
+    case "pushtopay_void_transaction":
+        res = h.voidTransaction(vars[0], vars[1])
+    default:
+        res = response{Status: http.StatusNotImplemented, Message: "Not Implemented"}
+    }
+
+    writeJSON(w, res)
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+    h.mu.Lock()
+    v := &ovo.Verifier{Keys: ovo.StaticKeys{h.AppID: h.APIKey}, MaxSkew: h.maxSkew}
+    h.mu.Unlock()
+
+    return v.Verify(r) == nil
+}
+
+func (h *Handler) match(method, path string) (string, []string) {
+    for _, rt := range h.routes {
+        if rt.method != method {
+            continue
+        }
+        if m := rt.re.FindStringSubmatch(path); m != nil {
+            return rt.name, m[1:]
+        }
+    }
+    return "", nil
+}
+
+func (h *Handler) customerProfile(c *Customer) response {
+    if c == nil {
+        return response{Status: http.StatusNotFound, Message: "Customer not found", Code: ovo.CustomerNotFound}
+    }
+    if c.Disabled {
+        return response{Status: http.StatusForbidden, Message: "Loyalty account is disabled", Code: ovo.LoyaltyAccountDisabled}
+    }
+    return response{Status: http.StatusOK, Data: profileData(c), Message: "Success", Code: ovo.Success}
+}
+
+func (h *Handler) customerLinkage(phone string, r *http.Request) response {
+    if c := h.findCustomer(phone); c != nil {
+        return response{Status: http.StatusConflict, Data: profileData(c), Message: "Customer already registered"}
+    }
+    c := h.addCustomer(Customer{
+        Phone:     phone,
+        Fullname:  r.Form.Get("fullname"),
+        Email:     r.Form.Get("email"),
+        Birthdate: r.Form.Get("birthdate"),
+    })
+    return response{Status: http.StatusCreated, Data: profileData(c), Message: "Success", Code: ovo.Success}
+}
+
+func (h *Handler) calculatePoints(customerID string, r *http.Request) response {
+    c := h.findCustomer(customerID)
+    if c == nil {
+        return response{Status: http.StatusNotFound, Message: "Customer not found", Code: ovo.CustomerNotFound}
+    }
+    if c.Disabled {
+        return response{Status: http.StatusForbidden, Message: "Loyalty account is disabled", Code: ovo.LoyaltyAccountDisabled}
+    }
+
+    invoice := r.Form.Get("merchant_invoice")
+    amount, _ := strconv.ParseInt(r.Form.Get("amount"), 10, 64)
+    if amount < 0 {
+        return response{Status: http.StatusBadRequest, Message: "Amount must not be negative", Code: ovo.AmountMustNotNegative}
+    }
+    if invoice != "" && h.invoices[invoice] {
+        return response{Status: http.StatusConflict, Message: "Duplicate merchant invoice", Code: ovo.DuplicateMerchantInvoice}
+    }
+    h.invoices[invoice] = true
+
+    //One point for every 100 spent
+    earned := amount / 100
+    c.Points += earned
+
+    return response{
+        Status: http.StatusOK,
+        Data: &ovo.ResponseData{
+            LoyaltyID:       c.LoyaltyID,
+            MerchantInvoice: invoice,
+            PointID:         h.nextID("P"),
+            PointEarned:     strconv.FormatInt(earned, 10),
+            PointTotal:      strconv.FormatInt(c.Points, 10),
+        },
+        Message: "Success",
+        Code:    ovo.Success,
+    }
+}
+
+func (h *Handler) customerAuthentication(r *http.Request) response {
+    o := h.nextOutcome()
+    if o == Unavailable {
+        return response{Status: http.StatusServiceUnavailable, Message: "Service Unavailable"}
+    }
+
+    phone := r.Form.Get("phone")
+    if h.findCustomer(phone) == nil {
+        return response{Status: http.StatusNotFound, Message: "Customer not found", Code: ovo.CustomerNotFound}
+    }
+
+    a := &Authentication{
+        ID:         h.nextID("A"),
+        Phone:      phone,
+        MerchantID: r.Form.Get("merchant_id"),
+        Status:     outcomeStatus(o),
+    }
+    h.authentications[a.ID] = a
+
+    return response{
+        Status:  http.StatusCreated,
+        Data:    &ovo.ResponseData{AuthenticationID: a.ID},
+        Message: "Sending authentication",
+        //Same code the sdk expects as sending authentication
+        Code: 1,
+    }
+}
+
+func (h *Handler) customerAuthenticationStatus(id string) response {
+    a, ok := h.authentications[id]
+    if !ok {
+        return response{Status: http.StatusNotFound, Message: "Authentication not found", Code: ovo.AuthIDNotFound}
+    }
+
+    switch a.Status {
+    case StatusApproved:
+        c := h.findCustomer(a.Phone)
+        if c == nil {
+            return response{Status: http.StatusNotFound, Message: "Customer not found", Code: ovo.CustomerNotFound}
+        }
+        return response{Status: http.StatusOK, Data: profileData(c), Message: "Authenticated", Code: ovo.Authenticated}
+    case StatusRejected:
+        return response{Status: http.StatusOK, Message: "Rejected by customer", Code: ovo.Unauthenticated}
+    }
+    return response{Status: http.StatusOK, Message: "Waiting for customer", Code: ovo.Unauthenticated}
+}
+
+func (h *Handler) createTransaction(customerID string, r *http.Request) response {
+    o := h.nextOutcome()
+    if o == Unavailable {
+        return response{Status: http.StatusServiceUnavailable, Message: "Service Unavailable"}
+    }
+
+    c := h.findCustomer(customerID)
+    if c == nil {
+        return response{Status: http.StatusNotFound, Message: "Customer not found", Code: ovo.CustomerNotFound}
+    }
+    if c.Disabled {
+        return response{Status: http.StatusForbidden, Message: "Loyalty account is disabled", Code: ovo.LoyaltyAccountDisabled}
+    }
+
+    amount, _ := strconv.ParseInt(r.Form.Get("amount"), 10, 64)
+    if amount < 0 {
+        return response{Status: http.StatusBadRequest, Message: "Amount must not be negative", Code: ovo.AmountMustNotNegative}
+    }
+
+    t := &Transaction{
+        ID:              h.nextID("T"),
+        CustomerID:      c.LoyaltyID,
+        MerchantInvoice: r.Form.Get("merchant_invoice"),
+        Amount:          amount,
+        Status:          outcomeStatus(o),
+    }
+    if t.Status == StatusApproved {
+        h.approveTransaction(t)
+    }
+    h.transactions[t.ID] = t
+
+    return response{Status: http.StatusCreated, Data: h.transactionData(t), Message: "Success", Code: ovo.Success}
+}
+
+func (h *Handler) transactionStatus(customerID, id string) response {
+    t, ok := h.transactions[id]
+    if !ok || h.findCustomer(customerID) == nil || h.findCustomer(customerID).LoyaltyID != t.CustomerID {
+        return response{Status: http.StatusNotFound, Message: "Transaction not found"}
+    }
+
+    res := response{Status: http.StatusOK, Data: h.transactionData(t)}
+    switch t.Status {
+    case StatusApproved:
+        res.Message, res.Code = "Success", ovo.Success
+    case StatusPending:
+        res.Message, res.Code = "Waiting for customer", TransactionPending
+    default:
+        res.Message, res.Code = "Transaction "+string(t.Status), TransactionFailed
+    }
+    return res
+}
+
+func (h *Handler) voidTransaction(customerID, id string) response {
+    t, ok := h.transactions[id]
+    if !ok || h.findCustomer(customerID) == nil || h.findCustomer(customerID).LoyaltyID != t.CustomerID {
+        return response{Status: http.StatusNotFound, Message: "Transaction not found"}
+    }
+    if t.Status != StatusApproved {
+        return response{Status: http.StatusConflict, Data: h.transactionData(t), Message: "Transaction cannot be voided"}
+    }
+
+    t.Status = StatusVoid
+    if c := h.customers[t.CustomerID]; c != nil {
+        c.Points -= t.Amount / 100
+    }
+    return response{Status: http.StatusOK, Data: h.transactionData(t), Message: "Success", Code: ovo.Success}
+}
+
+func (h *Handler) approveTransaction(t *Transaction) {
+    t.ApprovalCode = h.nextID("AP")
+    if c := h.customers[t.CustomerID]; c != nil {
+        c.Points += t.Amount / 100
+    }
+}
+
+func (h *Handler) transactionData(t *Transaction) *ovo.ResponseData {
+    d := &ovo.ResponseData{
+        OrderID:         t.ID,
+        MerchantInvoice: t.MerchantInvoice,
+        ApprovalCode:    t.ApprovalCode,
+    }
+    if c := h.customers[t.CustomerID]; c != nil {
+        d.CustomerFullname = c.Fullname
+        d.CustomerPhone = c.Phone
+    }
+    return d
+}
+
+func profileData(c *Customer) *ovo.ResponseData {
+    return &ovo.ResponseData{
+        LoyaltyID:  c.LoyaltyID,
+        Fullname:   c.Fullname,
+        Birthdate:  c.Birthdate,
+        Phone:      c.Phone,
+        Email:      c.Email,
+        Level:      c.Level,
+        PointTotal: strconv.FormatInt(c.Points, 10),
+    }
+}
+
+func outcomeStatus(o Outcome) Status {
+    switch o {
+    case Approve:
+        return StatusApproved
+    case Reject:
+        return StatusRejected
+    }
+    return StatusPending
+}
+
+func writeJSON(w http.ResponseWriter, res response) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(res.Status)
+    json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,370 @@
+//Package ovotest : In-process fake OVO loyalty-back server for integration tests
+package ovotest
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "fmt"
+    "net/http/httptest"
+    "regexp"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/kh411d/ovo"
+)
+
+//Outcome : Scripted result of the next customer action or OVO call
+type Outcome int
+
+const (
+    //Approve : Customer approves the authentication / transaction right away
+    Approve Outcome = iota
+
+    //Reject : Customer rejects the authentication / transaction
+    Reject
+
+    //Timeout : Customer never responds, authentication / transaction stays pending
+    Timeout
+
+    //Unavailable : OVO answers with 503 Service Unavailable
+    Unavailable
+)
+
+//Status : State of an authentication or transaction held by the fake server
+type Status string
+
+const (
+    //StatusPending : Waiting for the customer action
+    StatusPending Status = "pending"
+
+    //StatusApproved : Approved by the customer
+    StatusApproved Status = "approved"
+
+    //StatusRejected : Rejected by the customer
+    StatusRejected Status = "rejected"
+
+    //StatusVoid : Transaction has been voided by the merchant
+    StatusVoid Status = "void"
+)
+
+const (
+    //TransactionPending : Code returned by the fake server while a transaction waits for the customer
+    TransactionPending = 0
+
+    //TransactionFailed : Code returned by the fake server for a rejected transaction
+    TransactionFailed = 2
+)
+
+//Customer : OVO customer held by the fake server
+type Customer struct {
+    LoyaltyID string `json:"loyalty_id"`
+    Phone     string `json:"phone"`
+    Fullname  string `json:"fullname"`
+    Email     string `json:"email"`
+    Birthdate string `json:"birthdate"`
+    Level     string `json:"level"`
+    Points    int64  `json:"points"`
+    Disabled  bool   `json:"disabled"`
+}
+
+//Authentication : Customer authentication held by the fake server
+type Authentication struct {
+    ID         string `json:"id"`
+    Phone      string `json:"phone"`
+    MerchantID string `json:"merchant_id"`
+    Status     Status `json:"status"`
+}
+
+//Transaction : Push to pay transaction held by the fake server
+type Transaction struct {
+    ID              string `json:"id"`
+    CustomerID      string `json:"customer_id"`
+    MerchantInvoice string `json:"merchant_invoice"`
+    Amount          int64  `json:"amount"`
+    ApprovalCode    string `json:"approval_code"`
+    Status          Status `json:"status"`
+}
+
+//Handler : Fake OVO loyalty-back api, serves every endpoint of ovo.Endpoints
+type Handler struct {
+    AppID  string
+    APIKey string
+
+    mu              sync.Mutex
+    seq             int
+    latency         time.Duration
+    maxSkew         time.Duration
+    outcome         Outcome
+    outcomes        []Outcome
+    failures        []int
+    customers       map[string]*Customer
+    authentications map[string]*Authentication
+    transactions    map[string]*Transaction
+    invoices        map[string]bool
+    terminals       map[string]string
+    calls           map[string]int
+    routes          []route
+}
+
+//Server : Fake OVO server listening on a local address, use URL as ovo.New baseURL
+type Server struct {
+    *Handler
+    URL string
+
+    srv *httptest.Server
+}
+
+type route struct {
+    name   string
+    method string
+    re     *regexp.Regexp
+}
+
+//NewHandler : Constructor for fake OVO api accepting requests signed with appID and apiKey
+func NewHandler(appID, apiKey string) *Handler {
+    h := &Handler{
+        AppID:           appID,
+        APIKey:          apiKey,
+        outcome:         Approve,
+        customers:       map[string]*Customer{},
+        authentications: map[string]*Authentication{},
+        transactions:    map[string]*Transaction{},
+        invoices:        map[string]bool{},
+        terminals:       map[string]string{},
+        calls:           map[string]int{},
+    }
+
+    re := regexp.MustCompile(":[a-zA-Z0-9_]+")
+    for _, e := range ovo.Endpoints() {
+        pattern := "^" + re.ReplaceAllString(regexp.QuoteMeta(e.Path), "([^/]+)") + "$"
+        h.routes = append(h.routes, route{e.Name, e.Method, regexp.MustCompile(pattern)})
+    }
+
+    return h
+}
+
+//NewServer : Start a fake OVO server, Close must be called when done
+func NewServer(appID, apiKey string) *Server {
+    h := NewHandler(appID, apiKey)
+    srv := httptest.NewServer(h)
+    return &Server{Handler: h, URL: srv.URL, srv: srv}
+}
+
+//Close : Shut down the fake server
+func (s *Server) Close() {
+    s.srv.Close()
+}
+
+//AddCustomer : Register an OVO customer, LoyaltyID is generated when empty
+func (h *Handler) AddCustomer(c Customer) Customer {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    return *h.addCustomer(c)
+}
+
+func (h *Handler) addCustomer(c Customer) *Customer {
+    if c.LoyaltyID == "" {
+        c.LoyaltyID = h.nextID("8000")
+    }
+    if c.Level == "" {
+        c.Level = "OVO"
+    }
+    h.customers[c.LoyaltyID] = &c
+    return &c
+}
+
+//Customer : Get OVO customer by loyalty id or phone
+func (h *Handler) Customer(id string) (Customer, bool) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    c := h.findCustomer(id)
+    if c == nil {
+        return Customer{}, false
+    }
+    return *c, true
+}
+
+//Authentication : Get authentication by id
+func (h *Handler) Authentication(id string) (Authentication, bool) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    a, ok := h.authentications[id]
+    if !ok {
+        return Authentication{}, false
+    }
+    return *a, true
+}
+
+//Authentications : List authentications, filtered by status when given
+func (h *Handler) Authentications(status ...Status) []Authentication {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    list := []Authentication{}
+    for _, a := range h.authentications {
+        if len(status) == 0 || a.Status == status[0] {
+            list = append(list, *a)
+        }
+    }
+    return list
+}
+
+//Transaction : Get transaction by id
+func (h *Handler) Transaction(id string) (Transaction, bool) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    t, ok := h.transactions[id]
+    if !ok {
+        return Transaction{}, false
+    }
+    return *t, true
+}
+
+//Transactions : List transactions, filtered by status when given
+func (h *Handler) Transactions(status ...Status) []Transaction {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    list := []Transaction{}
+    for _, t := range h.transactions {
+        if len(status) == 0 || t.Status == status[0] {
+            list = append(list, *t)
+        }
+    }
+    return list
+}
+
+//SetTerminalCustomer : Customer returned by the QR profile lookup of a merchant terminal
+func (h *Handler) SetTerminalCustomer(merchantID, storeID, terminalID, loyaltyID string) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    h.terminals[merchantID+"/"+storeID+"/"+terminalID] = loyaltyID
+}
+
+//SetOutcome : Default outcome of customer actions and OVO calls
+func (h *Handler) SetOutcome(o Outcome) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    h.outcome = o
+}
+
+//ScriptOutcomes : Outcomes used, in order, by the next authentication / transaction requests before falling back to the default
+func (h *Handler) ScriptOutcomes(o ...Outcome) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    h.outcomes = append(h.outcomes, o...)
+}
+
+//FailNext : Answer the next n requests with the given HTTP status
+func (h *Handler) FailNext(status, n int) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    for i := 0; i < n; i++ {
+        h.failures = append(h.failures, status)
+    }
+}
+
+//SetLatency : Delay every response
+func (h *Handler) SetLatency(d time.Duration) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    h.latency = d
+}
+
+//SetMaxSkew : Reject requests whose random timestamp is older or newer than d, 0 disables the check
+func (h *Handler) SetMaxSkew(d time.Duration) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    h.maxSkew = d
+}
+
+//Approve : Customer approves a pending authentication or transaction
+func (h *Handler) Approve(id string) bool {
+    return h.resolve(id, StatusApproved)
+}
+
+//Reject : Customer rejects a pending authentication or transaction
+func (h *Handler) Reject(id string) bool {
+    return h.resolve(id, StatusRejected)
+}
+
+func (h *Handler) resolve(id string, status Status) bool {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    if a, ok := h.authentications[id]; ok && a.Status == StatusPending {
+        a.Status = status
+        return true
+    }
+    if t, ok := h.transactions[id]; ok && t.Status == StatusPending {
+        t.Status = status
+        if status == StatusApproved {
+            h.approveTransaction(t)
+        }
+        return true
+    }
+    return false
+}
+
+//Calls : Number of requests served for an endpoint name
+func (h *Handler) Calls(name string) int {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+
+    return h.calls[name]
+}
+
+//Sign : HMAC expected in the hmac header for the given random
+func (h *Handler) Sign(random string) string {
+    m := hmac.New(sha256.New, []byte(h.APIKey))
+    m.Write([]byte(h.AppID + random))
+    return fmt.Sprintf("%x", m.Sum(nil))
+}
+
+func (h *Handler) nextID(prefix string) string {
+    h.seq++
+    return fmt.Sprintf("%s%08d", prefix, h.seq)
+}
+
+func (h *Handler) nextOutcome() Outcome {
+    if len(h.outcomes) > 0 {
+        o := h.outcomes[0]
+        h.outcomes = h.outcomes[1:]
+        return o
+    }
+    return h.outcome
+}
+
+func (h *Handler) findCustomer(id string) *Customer {
+    if c, ok := h.customers[id]; ok {
+        return c
+    }
+    for _, c := range h.customers {
+        if c.Phone != "" && samePhone(c.Phone, id) {
+            return c
+        }
+    }
+    return nil
+}
+
+func samePhone(a, b string) bool {
+    trim := func(s string) string {
+        s = strings.TrimPrefix(s, "+")
+        if strings.HasPrefix(s, "62") {
+            return s[2:]
+        }
+        return strings.TrimPrefix(s, "0")
+    }
+    return trim(a) == trim(b)
+}
@@ -0,0 +1,237 @@
+package ovotest_test
+
+import (
+    "database/sql"
+    "net/http"
+    "testing"
+
+    "github.com/kh411d/ovo"
+    "github.com/kh411d/ovo/ovotest"
+    sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+const (
+    testAppID  = "hypermart"
+    testAPIKey = "084b13ecac81e1a8caf1775ad02bd5fa40e7219c8956dba11429a497a0e4cd89"
+    testPhone  = "081234567890"
+)
+
+func linkCustomer(t *testing.T, srv *ovotest.Server) (*ovo.MatahariMall, sqlmock.Sqlmock, func()) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnError(sql.ErrNoRows)
+    mock.ExpectQuery(`SELECT customer_id, fg_verified FROM customer_ovo`).WillReturnError(sql.ErrNoRows)
+    mock.ExpectQuery(`SELECT customer_id FROM customer_ovo_unlink`).WillReturnError(sql.ErrNoRows)
+    mock.ExpectBegin()
+    mock.ExpectExec(`INSERT INTO customer_ovo`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    client := ovo.New(srv.URL, testAPIKey, testAppID, "1")
+    mmsdk := client.GetMMsdk(db)
+
+    err = mmsdk.ValidateOvoIDAndAuthenticateToOvo(&ovo.Request{CustomerID: 12345, Phone: testPhone})
+    if err != nil {
+        t.Fatalf("Authentication should be sent, got %s", err)
+    }
+
+    return mmsdk, mock, func() { db.Close() }
+}
+
+func expectStoredLinkage(mock sqlmock.Sqlmock, authID string) {
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, nil, testPhone, authID, 0, testAppID)
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnRows(rows)
+}
+
+func TestLinkageApproved(t *testing.T) {
+    srv := ovotest.NewServer(testAppID, testAPIKey)
+    defer srv.Close()
+    customer := srv.AddCustomer(ovotest.Customer{Phone: testPhone, Fullname: "Budi"})
+
+    mmsdk, mock, closeDB := linkCustomer(t, srv)
+    defer closeDB()
+
+    auths := srv.Authentications(ovotest.StatusApproved)
+    if len(auths) != 1 {
+        t.Fatalf("Authentication should be approved by default")
+    }
+
+    expectStoredLinkage(mock, auths[0].ID)
+    mock.ExpectBegin()
+    mock.ExpectExec(`UPDATE customer_ovo`).WithArgs(1, auths[0].ID, customer.LoyaltyID, sqlmock.AnyArg(), 12345).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    info, err := mmsdk.CheckOvoStatus(12345)
+    if err != nil {
+        t.Fatalf("Should not return error upon approval, got %s", err)
+    }
+    if info.OvoID != customer.LoyaltyID || info.FgVerified != 1 {
+        t.Errorf("Linkage should be verified with loyalty id %s", customer.LoyaltyID)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("there were unfulfilled expectations: %s", err)
+    }
+}
+
+type rejectedSubscriber struct {
+    ovo.NopLinkageSubscriber
+    rejected int
+}
+
+func (s *rejectedSubscriber) OnLinkageRejected(ovo.LinkageEvent) { s.rejected++ }
+
+func TestLinkageRejected(t *testing.T) {
+    srv := ovotest.NewServer(testAppID, testAPIKey)
+    defer srv.Close()
+    srv.AddCustomer(ovotest.Customer{Phone: testPhone})
+    srv.ScriptOutcomes(ovotest.Reject)
+
+    mmsdk, mock, closeDB := linkCustomer(t, srv)
+    defer closeDB()
+    sub := &rejectedSubscriber{}
+    mmsdk.Subscribe(sub)
+
+    expectStoredLinkage(mock, srv.Authentications()[0].ID)
+
+    _, err := mmsdk.CheckOvoStatus(12345)
+    if err == nil || err.Error() != ovo.TErr("ovo_retry_verification", "en").Error() {
+        t.Errorf("Rejected authentication should ask to retry verification")
+    }
+    if sub.rejected != 1 {
+        t.Errorf("Rejected authentication should be published, got %d", sub.rejected)
+    }
+}
+
+func TestLinkagePending(t *testing.T) {
+    srv := ovotest.NewServer(testAppID, testAPIKey)
+    defer srv.Close()
+    srv.AddCustomer(ovotest.Customer{Phone: testPhone})
+    srv.SetOutcome(ovotest.Timeout)
+
+    mmsdk, mock, closeDB := linkCustomer(t, srv)
+    defer closeDB()
+    sub := &rejectedSubscriber{}
+    mmsdk.Subscribe(sub)
+
+    expectStoredLinkage(mock, srv.Authentications(ovotest.StatusPending)[0].ID)
+
+    _, err := mmsdk.CheckOvoStatus(12345)
+    if err == nil || err.Error() != ovo.TErr("ovo_retry_verification", "en").Error() {
+        t.Errorf("Pending authentication should ask to retry verification")
+    }
+    if sub.rejected != 0 {
+        t.Errorf("Pending authentication should not be published as rejected")
+    }
+}
+
+func TestLinkageTimeoutThenApproved(t *testing.T) {
+    srv := ovotest.NewServer(testAppID, testAPIKey)
+    defer srv.Close()
+    srv.AddCustomer(ovotest.Customer{Phone: testPhone})
+    srv.SetOutcome(ovotest.Timeout)
+
+    mmsdk, mock, closeDB := linkCustomer(t, srv)
+    defer closeDB()
+
+    authID := srv.Authentications(ovotest.StatusPending)[0].ID
+    expectStoredLinkage(mock, authID)
+    if _, err := mmsdk.CheckOvoStatus(12345); err == nil {
+        t.Errorf("Pending authentication should not be verified")
+    }
+
+    if !srv.Approve(authID) {
+        t.Fatalf("Pending authentication should be approved")
+    }
+    expectStoredLinkage(mock, authID)
+    mock.ExpectBegin()
+    mock.ExpectExec(`UPDATE customer_ovo`).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+    if _, err := mmsdk.CheckOvoStatus(12345); err != nil {
+        t.Errorf("Approved authentication should be verified, got %s", err)
+    }
+}
+
+func TestServiceUnavailable(t *testing.T) {
+    srv := ovotest.NewServer(testAppID, testAPIKey)
+    defer srv.Close()
+    srv.FailNext(http.StatusServiceUnavailable, 1)
+
+    client := ovo.New(srv.URL, testAPIKey, testAppID, "1")
+    _, err := client.GetCustomerProfile(testPhone)
+    if err == nil || !ovo.IsTemporary(err) {
+        t.Errorf("503 should return temporary error")
+    }
+}
+
+func TestInvalidHmac(t *testing.T) {
+    srv := ovotest.NewServer(testAppID, testAPIKey)
+    defer srv.Close()
+    srv.AddCustomer(ovotest.Customer{Phone: testPhone})
+
+    client := ovo.New(srv.URL, "wrong-key", testAppID, "1")
+
+    _, err := client.CustomerAuthentication(ovo.Params{"phone": testPhone})
+    if ovo.GetErrCategory(err) != ovo.ErrCategoryAuth {
+        t.Errorf("Unauthorized response should return auth error, got %v", err)
+    }
+    if srv.Calls("customer_authentication") != 0 {
+        t.Errorf("Request with invalid hmac must not be served")
+    }
+}
+
+func TestPushToPay(t *testing.T) {
+    srv := ovotest.NewServer(testAppID, testAPIKey)
+    defer srv.Close()
+    customer := srv.AddCustomer(ovotest.Customer{Phone: testPhone})
+    srv.ScriptOutcomes(ovotest.Timeout)
+
+    client := ovo.New(srv.URL, testAPIKey, testAppID, "1")
+    if _, err := client.CreateTransaction(customer.LoyaltyID, ovo.Params{"amount": "15000", "merchant_invoice": "INV-1"}); err != nil {
+        t.Fatalf("Transaction should be created, got %s", err)
+    }
+
+    trx := srv.Transactions(ovotest.StatusPending)
+    if len(trx) != 1 {
+        t.Fatalf("Transaction should wait for customer")
+    }
+    srv.Approve(trx[0].ID)
+
+    if _, err := client.CheckTransactionStatus(customer.LoyaltyID, trx[0].ID); err != nil {
+        t.Errorf("Transaction status should be readable, got %s", err)
+    }
+    if c, _ := srv.Customer(customer.LoyaltyID); c.Points != 150 {
+        t.Errorf("Approved transaction should earn points")
+    }
+}
+
+func TestCalculatePointsDuplicateInvoice(t *testing.T) {
+    srv := ovotest.NewServer(testAppID, testAPIKey)
+    defer srv.Close()
+    customer := srv.AddCustomer(ovotest.Customer{Phone: testPhone})
+
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    mmsdk := ovo.New(srv.URL, testAPIKey, testAppID, "1").GetMMsdk(db)
+    params := ovo.Params{"amount": "15000", "merchant_invoice": "INV-1"}
+    if err := mmsdk.CalculateHyperOvoPoint(customer.LoyaltyID, params); err != nil {
+        t.Fatalf("Points should be calculated, got %s", err)
+    }
+    if err := mmsdk.CalculateHyperOvoPoint(customer.LoyaltyID, params); err != nil {
+        t.Errorf("Retried calculation of the same invoice should succeed, got %s", err)
+    }
+    if c, _ := srv.Customer(customer.LoyaltyID); c.Points != 150 {
+        t.Errorf("Duplicate invoice should not earn points twice, got %d", c.Points)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("there were unfulfilled expectations: %s", err)
+    }
+}
@@ -0,0 +1,99 @@
+//Package phone : Normalize and validate Indonesian mobile phone numbers
+package phone
+
+import (
+    "errors"
+    "strings"
+)
+
+//CountryCode : Indonesia calling code
+const CountryCode = "62"
+
+var (
+    //ErrInvalid : Number contains other characters than digits, separators and a leading +
+    ErrInvalid = errors.New("phone: invalid number")
+
+    //ErrNotMobile : Number is not an Indonesian mobile number
+    ErrNotMobile = errors.New("phone: not an Indonesian mobile number")
+)
+
+//mobilePrefixes : Operator prefixes of the national significant number
+var mobilePrefixes = map[string]bool{
+    //Telkomsel
+    "811": true, "812": true, "813": true, "821": true, "822": true, "823": true, "851": true, "852": true, "853": true,
+    //Indosat
+    "814": true, "815": true, "816": true, "855": true, "856": true, "857": true, "858": true,
+    //XL
+    "817": true, "818": true, "819": true, "859": true, "877": true, "878": true, "879": true,
+    //Axis
+    "831": true, "832": true, "833": true, "838": true,
+    //Three
+    "895": true, "896": true, "897": true, "898": true, "899": true,
+    //Smartfren
+    "881": true, "882": true, "883": true, "884": true, "885": true, "886": true, "887": true, "888": true, "889": true,
+}
+
+const (
+    minSignificant = 9
+    maxSignificant = 12
+)
+
+//Normalize : E.164 form (+628...) of a number written as "+62 812-3456-7890", "62812...", "0812..." or "812..."
+func Normalize(s string) (string, error) {
+    nsn, err := significant(s)
+    if err != nil {
+        return "", err
+    }
+    return "+" + CountryCode + nsn, nil
+}
+
+//National : National form (08...) of a number accepted by Normalize, the format OVO and customer_ovo use
+func National(s string) (string, error) {
+    nsn, err := significant(s)
+    if err != nil {
+        return "", err
+    }
+    return "0" + nsn, nil
+}
+
+//Valid : Check if s is an Indonesian mobile number
+func Valid(s string) bool {
+    _, err := significant(s)
+    return err == nil
+}
+
+//significant : National significant number, without country code or trunk prefix
+func significant(s string) (string, error) {
+    s = strings.TrimSpace(s)
+
+    var b strings.Builder
+    for i, r := range s {
+        switch {
+        case r >= '0' && r <= '9':
+            b.WriteRune(r)
+        case r == '+' && i == 0:
+        case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+        default:
+            return "", ErrInvalid
+        }
+    }
+    digits := b.String()
+    if strings.HasPrefix(s, "+") && !strings.HasPrefix(digits, CountryCode) {
+        return "", ErrNotMobile
+    }
+
+    switch {
+    case strings.HasPrefix(digits, "00"+CountryCode):
+        digits = digits[2+len(CountryCode):]
+    case strings.HasPrefix(digits, CountryCode):
+        digits = digits[len(CountryCode):]
+    case strings.HasPrefix(digits, "0"):
+        digits = digits[1:]
+    }
+
+    if len(digits) < minSignificant || len(digits) > maxSignificant || !mobilePrefixes[digits[:3]] {
+        return "", ErrNotMobile
+    }
+
+    return digits, nil
+}
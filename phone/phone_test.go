@@ -0,0 +1,49 @@
+package phone
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+    valid := []string{
+        "+62 812-3456-7890",
+        "+6281234567890",
+        "6281234567890",
+        "006281234567890",
+        "081234567890",
+        "0812.3456.7890",
+        "(0812) 3456 7890",
+        "81234567890",
+    }
+    for _, s := range valid {
+        e164, err := Normalize(s)
+        if err != nil || e164 != "+6281234567890" {
+            t.Errorf("%q should normalize to +6281234567890, got %q %v", s, e164, err)
+        }
+        national, _ := National(s)
+        if national != "081234567890" {
+            t.Errorf("%q national form should be 081234567890, got %q", s, national)
+        }
+    }
+}
+
+func TestNormalizeInvalid(t *testing.T) {
+    invalid := map[string]error{
+        "abc0812345":       ErrInvalid,
+        "0812-3456-789x":   ErrInvalid,
+        "08+1234567890":    ErrInvalid,
+        "":                 ErrNotMobile,
+        "08080808":         ErrNotMobile,
+        "0218765432":       ErrNotMobile,
+        "08123456":         ErrNotMobile,
+        "0812345678901234": ErrNotMobile,
+        "+6581234567":      ErrNotMobile,
+        "0801234567890":    ErrNotMobile,
+    }
+    for s, want := range invalid {
+        if _, err := Normalize(s); err != want {
+            t.Errorf("%q should return %v, got %v", s, want, err)
+        }
+        if Valid(s) {
+            t.Errorf("%q should not be valid", s)
+        }
+    }
+}
@@ -0,0 +1,121 @@
+package ovo
+
+import (
+    "database/sql"
+    "fmt"
+    "net/http"
+    "sort"
+)
+
+//NewClientPool : Constructor for ClientPool, every Client sends its requests through transport, http.DefaultTransport when nil
+func NewClientPool(transport http.RoundTripper) *ClientPool {
+    if transport == nil {
+        transport = http.DefaultTransport
+    }
+    return &ClientPool{
+        transport: transport,
+        clients:   map[string]*Client{},
+        stores:    map[string]string{},
+    }
+}
+
+//Add : Build the Client of cfg under merchant key, stores are other keys resolving to the same Client
+func (p *ClientPool) Add(key string, cfg Config, stores ...string) (*Client, error) {
+    client, err := NewFromConfig(cfg)
+    if err != nil {
+        return nil, err
+    }
+    client.SetHTTPClient(&http.Client{Transport: p.transport, Timeout: client.getHTTPClient().Timeout})
+
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    if _, ok := p.lookup(key); ok {
+        return nil, fmt.Errorf("ovo: merchant key %s already in pool", key)
+    }
+    for _, s := range stores {
+        if _, ok := p.lookup(s); ok || s == key {
+            return nil, fmt.Errorf("ovo: store key %s already in pool", s)
+        }
+    }
+
+    p.clients[key] = client
+    for _, s := range stores {
+        p.stores[s] = key
+    }
+    return client, nil
+}
+
+//Get : Client of the merchant or store key
+func (p *ClientPool) Get(key string) (*Client, bool) {
+    p.mu.RLock()
+    defer p.mu.RUnlock()
+
+    return p.lookup(key)
+}
+
+func (p *ClientPool) lookup(key string) (*Client, bool) {
+    if c, ok := p.clients[key]; ok {
+        return c, true
+    }
+    if merchant, ok := p.stores[key]; ok {
+        return p.clients[merchant], true
+    }
+    return nil, false
+}
+
+//Keys : Merchant keys of the pool, sorted
+func (p *ClientPool) Keys() []string {
+    p.mu.RLock()
+    keys := make([]string, 0, len(p.clients))
+    for k := range p.clients {
+        keys = append(keys, k)
+    }
+    p.mu.RUnlock()
+
+    sort.Strings(keys)
+    return keys
+}
+
+//locale : Locale of errors not bound to a merchant, en when not set
+func (p *ClientPool) locale() string {
+    if p.LocaleID == "" {
+        return "en"
+    }
+    return p.LocaleID
+}
+
+//GetMMsdk : MatahariMall sdk of the merchant or store key, ForMerchant selects another merchant of the pool
+func (p *ClientPool) GetMMsdk(db *sql.DB, key string) (*MatahariMall, error) {
+    client, ok := p.Get(key)
+    if !ok {
+        return nil, TErr("ovo_unknown_merchant", p.locale())
+    }
+
+    mm := client.GetMMsdk(db)
+    mm.pool = p
+    return mm, nil
+}
+
+//ForMerchant : Copy of the sdk without request state calling OVO with the Client of the merchant or store key,
+//linkages are stored with the app id of that merchant as source
+func (c *MatahariMall) ForMerchant(key string) (*MatahariMall, error) {
+    if c.pool == nil {
+        return nil, TErr("ovo_unknown_merchant", c.API.LocaleID)
+    }
+    client, ok := c.pool.Get(key)
+    if !ok {
+        return nil, TErr("ovo_unknown_merchant", c.API.LocaleID)
+    }
+
+    cp := c.clone()
+    cp.API = client
+    if c.API.ctx != nil {
+        cp.API = client.WithContext(c.API.ctx)
+    }
+    //The sdk logger redacts the api key of its client, redact the one of the selected merchant instead
+    if l, ok := c.logger.(*redactLogger); ok {
+        cp.logger = newRedactLogger(l.next, cp.API)
+    }
+    return cp, nil
+}
@@ -0,0 +1,165 @@
+package ovo
+
+import (
+    "database/sql"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type countTransport struct {
+    apps []string
+}
+
+func (t *countTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    t.apps = append(t.apps, req.Header.Get("app-id"))
+    return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientPool(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusCreated)
+        w.Write([]byte(`{"status": 201, "data": {"authentication_id": "666"}, "message": "Success", "code": 1}`))
+    }))
+    defer srv.Close()
+
+    transport := &countTransport{}
+    pool := NewClientPool(transport)
+    if _, err := pool.Add("brand-a", Config{BaseURL: srv.URL, APIKey: "key-a", AppID: "app-a", MerchantID: "1"}, "store-a1"); err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    if _, err := pool.Add("brand-b", Config{BaseURL: srv.URL, APIKey: "key-b", AppID: "app-b", MerchantID: "2"}, "store-b1", "store-b2"); err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    if _, err := pool.Add("brand-c", Config{BaseURL: srv.URL, APIKey: "key-c", AppID: "app-c", MerchantID: "3"}, "store-a1"); err == nil {
+        t.Errorf("Store key should belong to one merchant")
+    }
+    if _, err := pool.Add("brand-d", Config{BaseURL: srv.URL, AppID: "app-d", MerchantID: "4"}); err == nil {
+        t.Errorf("Invalid config should not be added")
+    }
+
+    if c, ok := pool.Get("store-b2"); !ok || c.AppID != "app-b" {
+        t.Errorf("Store key should resolve to its merchant")
+    }
+    if _, ok := pool.Get("brand-x"); ok {
+        t.Errorf("Unknown key should not resolve")
+    }
+    if keys := pool.Keys(); len(keys) != 2 || keys[0] != "brand-a" || keys[1] != "brand-b" {
+        t.Errorf("Keys should list merchants, got %v", keys)
+    }
+
+    a, _ := pool.Get("brand-a")
+    b, _ := pool.Get("brand-b")
+    a.CustomerAuthentication(nil)
+    b.CustomerAuthentication(nil)
+    if len(transport.apps) != 2 || transport.apps[0] != "app-a" || transport.apps[1] != "app-b" {
+        t.Errorf("Clients should share the transport, got %v", transport.apps)
+    }
+}
+
+func TestMMsdkForMerchant(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusCreated)
+        w.Write([]byte(`{"status": 201, "data": {"authentication_id": "666"}, "message": "Success", "code": 1}`))
+    }))
+    defer srv.Close()
+
+    pool := NewClientPool(nil)
+    pool.Add("brand-a", Config{BaseURL: srv.URL, APIKey: "key-a", AppID: "app-a", MerchantID: "1"})
+    pool.Add("brand-b", Config{BaseURL: srv.URL, APIKey: "key-b", AppID: "app-b", MerchantID: "2"}, "store-b1")
+
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnError(sql.ErrNoRows)
+    mock.ExpectQuery(`SELECT customer_id, fg_verified FROM customer_ovo`).WillReturnError(sql.ErrNoRows)
+    mock.ExpectQuery(`SELECT customer_id FROM customer_ovo_unlink`).WillReturnError(sql.ErrNoRows)
+    mock.ExpectBegin()
+    mock.ExpectExec(`INSERT INTO customer_ovo`).WithArgs(12345, sqlmock.AnyArg(), sqlmock.AnyArg(), "666", "app-b").WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    mmsdk, err := pool.GetMMsdk(db, "brand-a")
+    if err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    if _, err := mmsdk.ForMerchant("brand-x"); err == nil {
+        t.Errorf("Unknown merchant should be an error")
+    }
+
+    store, err := mmsdk.ForMerchant("store-b1")
+    if err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    if err := store.ValidateOvoIDAndAuthenticateToOvo(&Request{CustomerID: 12345, Phone: "081208080808"}); err != nil {
+        t.Fatalf("This should not error expect success, got %s", err)
+    }
+    if mmsdk.API.AppID != "app-a" {
+        t.Errorf("ForMerchant should not change the sdk it was called on")
+    }
+
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, nil, "081208080808", "666", 0, "app-b")
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnRows(rows)
+    mock.ExpectQuery(`SELECT customer_id, fg_verified FROM customer_ovo`).WillReturnRows(sqlmock.NewRows([]string{"customer_id", "fg_verified"}).AddRow(12345, 0))
+    mock.ExpectQuery(`SELECT customer_id FROM customer_ovo_unlink`).WillReturnError(sql.ErrNoRows)
+    mock.ExpectBegin()
+    mock.ExpectExec(`UPDATE customer_ovo SET .*source = \?`).WithArgs(0, "666", sqlmock.AnyArg(), sqlmock.AnyArg(), "app-a", 12345).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    if err := mmsdk.ValidateOvoIDAndAuthenticateToOvo(&Request{CustomerID: 12345, Phone: "081208080808"}); err != nil {
+        t.Fatalf("Relinking through another merchant should update the source, got %s", err)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("there were unfulfilled expectations: %s", err)
+    }
+
+    if _, err := new(Client).GetMMsdk(db).ForMerchant("brand-a"); err == nil {
+        t.Errorf("Sdk without pool should not select merchant")
+    }
+}
+
+func TestClientPoolLocale(t *testing.T) {
+    pool := NewClientPool(nil)
+    pool.Add("brand-a", Config{BaseURL: "http://localhost", APIKey: "key-a", AppID: "app-a", MerchantID: "1", LocaleID: "id"})
+
+    if _, err := pool.GetMMsdk(nil, "brand-x"); err == nil || err.Error() != TErr("ovo_unknown_merchant", "en").Error() {
+        t.Errorf("Unknown merchant should be reported in en by default, got %v", err)
+    }
+    pool.LocaleID = "id"
+    if _, err := pool.GetMMsdk(nil, "brand-x"); err == nil || err.Error() != TErr("ovo_unknown_merchant", "id").Error() {
+        t.Errorf("Unknown merchant should be reported in the pool locale, got %v", err)
+    }
+}
+
+func TestMMsdkForMerchantLogger(t *testing.T) {
+    pool := NewClientPool(nil)
+    pool.Add("brand-a", Config{BaseURL: "http://localhost", APIKey: "key-a", AppID: "app-a", MerchantID: "1"})
+    pool.Add("brand-b", Config{BaseURL: "http://localhost", APIKey: "key-b", AppID: "app-b", MerchantID: "2"})
+
+    mmsdk, _ := pool.GetMMsdk(nil, "brand-a")
+    rec := &recordLogger{}
+    mmsdk.SetLogger(rec)
+
+    b, err := mmsdk.ForMerchant("brand-b")
+    if err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    b.log().Info("signing with key-b")
+    mmsdk.log().Info("signing with key-a")
+
+    for _, line := range rec.lines {
+        if strings.Contains(line, "key-") {
+            t.Errorf("Log should redact the api key of the merchant: %s", line)
+        }
+    }
+    if len(rec.lines) != 2 {
+        t.Errorf("Selected merchant should keep the sdk logger, got %v", rec.lines)
+    }
+}
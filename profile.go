@@ -0,0 +1,152 @@
+package ovo
+
+import (
+    "container/list"
+    "time"
+
+    "github.com/kh411d/ovo/phone"
+)
+
+//NewLRUProfileCache : Constructor for LRUProfileCache keeping at most size profiles
+func NewLRUProfileCache(size int) *LRUProfileCache {
+    return &LRUProfileCache{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+//Get : Cached profile of key
+func (c *LRUProfileCache) Get(key string) (CachedProfile, bool) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    e, ok := c.entries[key]
+    if !ok {
+        return CachedProfile{}, false
+    }
+    c.order.MoveToFront(e)
+    return e.Value.(*lruEntry).profile, true
+}
+
+//Set : Cache profile of key, evicting the least recently used profile when full
+func (c *LRUProfileCache) Set(key string, p CachedProfile) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if e, ok := c.entries[key]; ok {
+        e.Value.(*lruEntry).profile = p
+        c.order.MoveToFront(e)
+        return
+    }
+
+    c.entries[key] = c.order.PushFront(&lruEntry{key, p})
+    for c.size > 0 && c.order.Len() > c.size {
+        last := c.order.Back()
+        c.order.Remove(last)
+        delete(c.entries, last.Value.(*lruEntry).key)
+    }
+}
+
+//Delete : Drop cached profile of key
+func (c *LRUProfileCache) Delete(key string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if e, ok := c.entries[key]; ok {
+        c.order.Remove(e)
+        delete(c.entries, key)
+    }
+}
+
+//SetProfileCache : Serve GetCustomerProfile from cache for ttl, then up to maxStale longer while OVO is unavailable.
+//Nil cache disables caching
+func (client *Client) SetProfileCache(cache ProfileCache, ttl, maxStale time.Duration) {
+    client.profileCache = cache
+    client.profileTTL = ttl
+    client.profileMaxStale = maxStale
+}
+
+//InvalidateProfile : Drop the cached profiles of the customers, by loyalty id or phone
+func (client *Client) InvalidateProfile(customerIDs ...string) {
+    if client.profileCache == nil {
+        return
+    }
+    for _, id := range customerIDs {
+        if id != "" {
+            client.profileCache.Delete(client.profileKey(id))
+        }
+    }
+}
+
+//profileKey : Cache key of the customer scoped by app id and base url, so clients can share a cache.
+//Phones in any format share the key
+func (client *Client) profileKey(customerID string) string {
+    if p, err := phone.Normalize(customerID); err == nil {
+        customerID = p
+    }
+    return "profile:" + client.AppID + "@" + client.BaseURL + ":" + customerID
+}
+
+func (client *Client) freshProfile(customerID string) ([]byte, bool) {
+    if client.profileCache == nil {
+        return nil, false
+    }
+
+    p, ok := client.profileCache.Get(client.profileKey(customerID))
+    if !ok || time.Since(p.StoredAt) >= client.profileTTL {
+        return nil, false
+    }
+    return p.Data, true
+}
+
+//staleProfile : Expired profile still within maxStale, only when err tells OVO is unavailable
+func (client *Client) staleProfile(customerID string, err error) ([]byte, bool) {
+    if client.profileCache == nil || !IsTemporary(err) {
+        return nil, false
+    }
+
+    p, ok := client.profileCache.Get(client.profileKey(customerID))
+    age := time.Since(p.StoredAt)
+    if !ok || age >= client.profileTTL+client.profileMaxStale {
+        return nil, false
+    }
+
+    client.log().Warn("ovo profile served stale", "customer_id", customerID, "age", age, "error", err)
+    return p.Data, true
+}
+
+//storeProfile : Cache successful profile response under customerIDs and the loyalty id and phone it holds
+func (client *Client) storeProfile(data []byte, customerIDs ...string) {
+    if client.profileCache == nil || responseCode(data) != Success {
+        return
+    }
+
+    if r, err := client.getResponse(data); err == nil {
+        customerIDs = append(customerIDs, r.Data.LoyaltyID, r.Data.Phone)
+    }
+
+    p := CachedProfile{Data: data, StoredAt: time.Now()}
+    for _, id := range customerIDs {
+        if id != "" {
+            client.profileCache.Set(client.profileKey(id), p)
+        }
+    }
+}
+
+//invalidateProfiles : Drop cached profiles of the linkages once their change is committed, from every client of the pool
+func (c *MatahariMall) invalidateProfiles(linkages ...*CustomerOvo) {
+    clients := []*Client{c.API}
+    if c.pool != nil {
+        for _, key := range c.pool.Keys() {
+            if client, ok := c.pool.Get(key); ok {
+                clients = append(clients, client)
+            }
+        }
+    }
+
+    for _, l := range linkages {
+        if l == nil {
+            continue
+        }
+        for _, client := range clients {
+            client.InvalidateProfile(l.OvoID, l.OvoPhone)
+        }
+    }
+}
@@ -0,0 +1,186 @@
+package ovo
+
+import (
+    "net/http"
+    "testing"
+    "time"
+
+    sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+const testProfile = `{"status": 200, "data": {"loyalty_id": "8000428048133600", "phone": "081208080808", "level": "OVO Premier"}, "message": "Success", "code": 1}`
+
+func TestLRUProfileCache(t *testing.T) {
+    cache := NewLRUProfileCache(2)
+    cache.Set("a", CachedProfile{Data: []byte("a")})
+    cache.Set("b", CachedProfile{Data: []byte("b")})
+    cache.Get("a")
+    cache.Set("c", CachedProfile{Data: []byte("c")})
+
+    if _, ok := cache.Get("b"); ok {
+        t.Errorf("Least recently used profile should be evicted")
+    }
+    if p, ok := cache.Get("a"); !ok || string(p.Data) != "a" {
+        t.Errorf("Recently used profile should be kept")
+    }
+
+    cache.Delete("a")
+    if _, ok := cache.Get("a"); ok {
+        t.Errorf("Deleted profile should be gone")
+    }
+}
+
+func TestProfileCache(t *testing.T) {
+    calls := 0
+    status := http.StatusOK
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        calls++
+        w.WriteHeader(status)
+        w.Write([]byte(testProfile))
+    }
+    cache := NewLRUProfileCache(100)
+    client.SetProfileCache(cache, time.Minute, time.Hour)
+
+    client.GetCustomerProfile("081208080808")
+    data, err := client.GetCustomerProfile("+6281208080808")
+    if err != nil || string(data) != testProfile || calls != 1 {
+        t.Errorf("Fresh profile should be served from cache")
+    }
+    if _, err := client.GetCustomerProfile("8000428048133600"); err != nil || calls != 1 {
+        t.Errorf("Profile should be cached by loyalty id too")
+    }
+
+    expire := func(age time.Duration) {
+        for _, k := range []string{"081208080808", "8000428048133600"} {
+            p, _ := cache.Get(client.profileKey(k))
+            p.StoredAt = time.Now().Add(-age)
+            cache.Set(client.profileKey(k), p)
+        }
+    }
+
+    expire(2 * time.Minute)
+    status = http.StatusServiceUnavailable
+    data, err = client.GetCustomerProfile("081208080808")
+    if err != nil || string(data) != testProfile || calls != 2 {
+        t.Errorf("Stale profile should be served while OVO is unavailable")
+    }
+
+    expire(2 * time.Hour)
+    if _, err := client.GetCustomerProfile("081208080808"); err == nil {
+        t.Errorf("Profile older than max stale should not be served")
+    }
+
+    status = http.StatusOK
+    client.GetCustomerProfile("081208080808")
+    client.InvalidateProfile("8000428048133600", "081208080808")
+    client.GetCustomerProfile("081208080808")
+    if calls != 5 {
+        t.Errorf("Invalidated profile should be fetched again, got %d calls", calls)
+    }
+}
+
+func TestProfileCacheQR(t *testing.T) {
+    calls := 0
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        calls++
+        w.Write([]byte(testProfile))
+    }
+    client.SetProfileCache(NewLRUProfileCache(100), time.Minute, 0)
+
+    client.GetCustomerProfileQR("1", "2", "3")
+    client.GetCustomerProfileQR("1", "2", "3")
+    if calls != 2 {
+        t.Errorf("QR profile should always ask OVO who is at the terminal")
+    }
+    client.GetCustomerProfile("8000428048133600")
+    if calls != 2 {
+        t.Errorf("QR profile should be cached by customer")
+    }
+}
+
+func TestProfileCacheInvalidatedOnUnlink(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, "8000428048133600", "081208080808", "123", 1, "hypermart")
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnRows(rows)
+    mock.ExpectBegin()
+    mock.ExpectExec(`INSERT INTO customer_ovo_unlink`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectExec(`DELETE FROM customer_ovo`).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(testProfile))
+    }
+    cache := NewLRUProfileCache(100)
+    client.SetProfileCache(cache, time.Hour, 0)
+    client.GetCustomerProfile("8000428048133600")
+
+    if err := client.GetMMsdk(db).UnlinkCustomer(12345, UnlinkPhoneLost, "cs:andi"); err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    for _, k := range []string{"8000428048133600", "081208080808"} {
+        if _, ok := cache.Get(client.profileKey(k)); ok {
+            t.Errorf("Profile of unlinked customer should be invalidated")
+        }
+    }
+}
+
+func TestProfileCacheScopedByClient(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, "8000428048133600", "081208080808", "123", 1, "hypermart")
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnRows(rows)
+    mock.ExpectBegin()
+    mock.ExpectExec(`INSERT INTO customer_ovo_unlink`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectExec(`DELETE FROM customer_ovo`).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    calls := 0
+    cache := NewLRUProfileCache(100)
+    pool := NewClientPool(nil)
+    for _, app := range []string{"app-a", "app-b"} {
+        client, err := pool.Add(app, Config{BaseURL: "http://testing.com", APIKey: "secret", AppID: app, MerchantID: "1"})
+        if err != nil {
+            t.Fatalf("This should not error, got %s", err)
+        }
+        client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+            calls++
+            w.Write([]byte(testProfile))
+        }
+        client.SetProfileCache(cache, time.Hour, 0)
+    }
+    a, _ := pool.Get("app-a")
+    b, _ := pool.Get("app-b")
+
+    a.GetCustomerProfile("8000428048133600")
+    b.GetCustomerProfile("8000428048133600")
+    if calls != 2 {
+        t.Errorf("Clients sharing a cache should not serve each other profiles, got %d calls", calls)
+    }
+
+    mmsdk, err := pool.GetMMsdk(db, "app-a")
+    if err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    if err := mmsdk.UnlinkCustomer(12345, UnlinkPhoneLost, "cs:andi"); err != nil {
+        t.Fatalf("This should not error, got %s", err)
+    }
+    for _, client := range []*Client{a, b} {
+        if _, ok := cache.Get(client.profileKey("8000428048133600")); ok {
+            t.Errorf("Profile of unlinked customer should be invalidated for every client of the pool")
+        }
+    }
+}
@@ -0,0 +1,51 @@
+package ovo
+
+import (
+    "context"
+    "time"
+)
+
+//NewTokenBucket : Constructor for TokenBucket, starts full. A rate <= 0 does not limit
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+    if burst < 1 {
+        burst = 1
+    }
+    return &TokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+//Wait : Take a token, waiting for one to be refilled
+func (b *TokenBucket) Wait(ctx context.Context) error {
+    if b.rate <= 0 {
+        return ctx.Err()
+    }
+    for {
+        b.mu.Lock()
+        now := time.Now()
+        b.tokens += now.Sub(b.last).Seconds() * b.rate
+        if b.tokens > b.burst {
+            b.tokens = b.burst
+        }
+        b.last = now
+
+        if b.tokens >= 1 {
+            b.tokens--
+            b.mu.Unlock()
+            return nil
+        }
+        wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+        b.mu.Unlock()
+
+        t := time.NewTimer(wait)
+        select {
+        case <-t.C:
+        case <-ctx.Done():
+            t.Stop()
+            return ctx.Err()
+        }
+    }
+}
+
+//SetRateLimiter : Wait for limiter before sending each request to OVO, nil sends right away
+func (client *Client) SetRateLimiter(limiter RateLimiter) {
+    client.limiter = limiter
+}
@@ -0,0 +1,79 @@
+package ovo
+
+import (
+    "context"
+    "database/sql"
+    "net/http"
+)
+
+//Attr : Span attribute
+func Attr(key string, value interface{}) Attribute {
+    return Attribute{Key: key, Value: value}
+}
+
+//SetTracer : Setting tracer of OVO calls and SQL statements, nothing is traced when not set
+func (client *Client) SetTracer(t Tracer) {
+    client.tracer = t
+}
+
+//WithContext : Copy of the client whose spans are children of the span in ctx
+func (client *Client) WithContext(ctx context.Context) *Client {
+    cp := *client
+    cp.ctx = ctx
+    return &cp
+}
+
+//WithContext : Copy of the sdk without request state whose spans are children of the span in ctx
+func (c *MatahariMall) WithContext(ctx context.Context) *MatahariMall {
+    cp := c.clone()
+    cp.API = c.API.WithContext(ctx)
+    return cp
+}
+
+func (client *Client) context() context.Context {
+    if client == nil || client.ctx == nil {
+        return context.Background()
+    }
+    return client.ctx
+}
+
+//startSpan : Start span, the returned function sets the error status when err is not nil and ends the span
+func (client *Client) startSpan(name string, attrs ...Attribute) (context.Context, Span, func(err error)) {
+    ctx := client.context()
+    if client == nil || client.tracer == nil {
+        return ctx, nopSpan{}, func(error) {}
+    }
+
+    ctx, span := client.tracer.Start(ctx, name, attrs...)
+    return ctx, span, func(err error) {
+        if err != nil && err != sql.ErrNoRows {
+            span.RecordError(err)
+        }
+        span.End()
+    }
+}
+
+//sqlSpan : Start span around one SQL statement, end it with the statement error
+func (c *MatahariMall) sqlSpan(operation, table string) func(err error) {
+    _, _, end := c.API.startSpan("ovo.sql "+operation+" "+table,
+        Attr("db.operation", operation),
+        Attr("db.sql.table", table),
+    )
+    return end
+}
+
+//injectTrace : Propagate the span in ctx to OVO through the request headers
+func (client *Client) injectTrace(ctx context.Context, header http.Header) {
+    if client.tracer != nil {
+        client.tracer.Inject(ctx, header)
+    }
+}
+
+//SetAttributes : Ignore attributes
+func (nopSpan) SetAttributes(...Attribute) {}
+
+//RecordError : Ignore error
+func (nopSpan) RecordError(error) {}
+
+//End : Ignore end
+func (nopSpan) End() {}
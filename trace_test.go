@@ -0,0 +1,135 @@
+package ovo
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type ctxKey struct{}
+
+type recordSpan struct {
+    name   string
+    parent string
+    attrs  map[string]interface{}
+    err    error
+    ended  bool
+}
+
+func (s *recordSpan) SetAttributes(attrs ...Attribute) {
+    for _, a := range attrs {
+        s.attrs[a.Key] = a.Value
+    }
+}
+func (s *recordSpan) RecordError(err error) { s.err = err }
+func (s *recordSpan) End()                  { s.ended = true }
+
+type recordTracer struct {
+    spans []*recordSpan
+}
+
+func (t *recordTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+    parent, _ := ctx.Value(ctxKey{}).(string)
+    s := &recordSpan{name: name, parent: parent, attrs: map[string]interface{}{}}
+    s.SetAttributes(attrs...)
+    t.spans = append(t.spans, s)
+    return context.WithValue(ctx, ctxKey{}, name), s
+}
+
+func (t *recordTracer) Inject(ctx context.Context, header http.Header) {
+    name, _ := ctx.Value(ctxKey{}).(string)
+    header.Set("traceparent", name)
+}
+
+func TestTraceRequest(t *testing.T) {
+    var traceparent string
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        traceparent = r.Header.Get("traceparent")
+        w.Write([]byte(`{"status": 200, "code": 1}`))
+    }))
+    defer srv.Close()
+
+    tracer := &recordTracer{}
+    client := New(srv.URL, "secret", "hypermart", "1")
+    client.SetTracer(tracer)
+
+    ctx := context.WithValue(context.Background(), ctxKey{}, "checkout")
+    if _, err := client.WithContext(ctx).GetCustomerProfile("081234567890"); err != nil {
+        t.Fatal(err)
+    }
+
+    if len(tracer.spans) != 1 {
+        t.Fatalf("This should start one span, got %d", len(tracer.spans))
+    }
+    s := tracer.spans[0]
+    if s.name != "ovo.customer_profile" || s.parent != "checkout" || !s.ended {
+        t.Errorf("Span should be a child of the context span and ended, got %+v", s)
+    }
+    if s.attrs["http.status_code"] != 200 || s.attrs["ovo.code"] != 1 || s.attrs["http.method"] != "GET" {
+        t.Errorf("Span should have status and OVO code attributes, got %v", s.attrs)
+    }
+    if traceparent != "ovo.customer_profile" {
+        t.Errorf("Span should be propagated to OVO, got %q", traceparent)
+    }
+}
+
+func TestTraceSQL(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    mock.ExpectExec(`INSERT INTO ovo_points`).WillReturnError(errors.New("deadlock"))
+
+    tracer := &recordTracer{}
+    client := new(Client)
+    client.SetTracer(tracer)
+    mmsdk := client.GetMMsdk(db).WithContext(context.WithValue(context.Background(), ctxKey{}, "checkout"))
+
+    mmsdk.AddOvoPointHistory(1, 2, "SO1", "earn", Params{})
+
+    if len(tracer.spans) != 1 {
+        t.Fatalf("This should start one span, got %d", len(tracer.spans))
+    }
+    s := tracer.spans[0]
+    if s.name != "ovo.sql INSERT ovo_points" || s.parent != "checkout" || s.err == nil || !s.ended {
+        t.Errorf("SQL span should record the error, got %+v", s)
+    }
+}
+
+func TestTraceBackfillSQL(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    rows := sqlmock.NewRows([]string{"customer_id", "customer_id", "ovo_phone"}).AddRow(1, 1, "081234567890")
+    mock.ExpectQuery(`SELECT customer_id, customer_id, ovo_phone FROM customer_ovo WHERE`).WillReturnRows(rows)
+    mock.ExpectExec(`UPDATE customer_ovo SET ovo_phone_lookup`).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectQuery(`SELECT id, customer_id, ovo_phone FROM customer_ovo_unlink`).WillReturnError(errors.New("deadlock"))
+
+    tracer := &recordTracer{}
+    client := new(Client)
+    client.SetTracer(tracer)
+
+    client.GetMMsdk(db).BackfillPhoneLookup(2)
+
+    names := []string{"ovo.sql SELECT customer_ovo", "ovo.sql UPDATE customer_ovo", "ovo.sql SELECT customer_ovo_unlink"}
+    if len(tracer.spans) != len(names) {
+        t.Fatalf("This should start %d spans, got %d", len(names), len(tracer.spans))
+    }
+    for i, s := range tracer.spans {
+        if s.name != names[i] || !s.ended {
+            t.Errorf("SQL span %d should be %s, got %+v", i, names[i], s)
+        }
+    }
+    if tracer.spans[2].err == nil {
+        t.Errorf("SQL span should record the error")
+    }
+}
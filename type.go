@@ -1,9 +1,12 @@
 package ovo
 
 import (
+    "container/list"
+    "context"
     "database/sql"
     "net/http"
-    "net/http/httptest"
+    "regexp"
+    "sync"
     "time"
 )
 
@@ -13,13 +16,35 @@ type Client struct {
     APIKey     string
     AppID      string
     MerchantID string
-    Random     string
-    Hmac       string
-    LocaleID   string
+    //Deprecated: Random is the random of the construction time, every request is signed with its own
+    Random string
+    //Deprecated: Hmac is the signature of Random, every request is signed with its own
+    Hmac     string
+    LocaleID string
+
+    //Environment : Environment given to NewFromConfig, empty when built with New
+    Environment Environment
+
+    httpClient *http.Client
+    logger     Logger
+    metrics    Metrics
+    tracer     Tracer
+    ctx        context.Context
+
+    middlewares []Middleware
+    credentials CredentialProvider
+
+    profileCache    ProfileCache
+    profileTTL      time.Duration
+    profileMaxStale time.Duration
+
+    //flight : Coalesce concurrent identical GET requests, nil sends each of them
+    flight *flightGroup
+
+    limiter RateLimiter
 
     //For testing purpose
     httpHandler func(http.ResponseWriter, *http.Request)
-    wRes        *httptest.ResponseRecorder
 }
 
 //MatahariMall : Type for MatahariMall sdk
@@ -28,6 +53,19 @@ type MatahariMall struct {
     API     *Client
     OvoInfo *CustomerOvo
     OvoReq  *Request
+
+    //UnlinkCooldown : Period a phone unlinked from a customer cannot be linked by another customer, 0 disables the check
+    UnlinkCooldown time.Duration
+
+    //PhoneCipher : Encrypt stored phones, nil stores them in plaintext
+    PhoneCipher *PhoneCipher
+
+    subscribers []LinkageSubscriber
+    logger      Logger
+    pool        *ClientPool
+
+    //stored : Linkage as last read from or written to customer_ovo, old values of the next history row
+    stored *CustomerOvo
 }
 
 //Params : Type for api parameters
@@ -44,6 +82,12 @@ type Request struct {
     TerminalID    string
     FgVerified    int
     Source        string
+
+    //Actor : Who asks for the change (customer, customer service user, ...), recorded in customer_ovo_history
+    Actor string
+
+    //Reason : Why the linkage changes, recorded in customer_ovo_history
+    Reason string
 }
 
 //CustomerOvo : Type for struct name and field the same as db table
@@ -88,6 +132,445 @@ type ResponseData struct {
 
 //CustomError : Ovo Error handler
 type CustomError struct {
-    code int
-    msg  string
+    code      int
+    msg       string
+    key       string
+    category  ErrCategory
+    temporary bool
+    retryable bool
+    err       error
+}
+
+//ErrCategory : Category of an error returned by the sdk
+type ErrCategory string
+
+type errKind struct {
+    category  ErrCategory
+    temporary bool
+}
+
+//EventType : Type of OVO callback notification
+type EventType string
+
+//Notification : OVO callback payload, event is either "transaction" or "authentication"
+type Notification struct {
+    Event   string       `json:"event"`
+    Code    int          `json:"code"`
+    Message string       `json:"message"`
+    Data    ResponseData `json:"data"`
+}
+
+//TransactionEvent : Decoded transaction callback
+type TransactionEvent struct {
+    Type            EventType
+    Code            int
+    Message         string
+    OrderID         string
+    MerchantInvoice string
+    ApprovalCode    string
+    CustomerPhone   string
+    Data            ResponseData
+}
+
+//AuthenticationEvent : Decoded authentication callback, CustomerOvo is the stored linkage after the callback was applied
+type AuthenticationEvent struct {
+    Type             EventType
+    Code             int
+    Message          string
+    AuthenticationID string
+    LoyaltyID        string
+    Phone            string
+    CustomerOvo      *CustomerOvo
+    Data             ResponseData
+}
+
+//TransactionHandler : Handle transaction callback, returning an error answers the notification with 500
+type TransactionHandler func(TransactionEvent) error
+
+//AuthenticationHandler : Handle authentication callback, returning an error answers the notification with 500
+type AuthenticationHandler func(AuthenticationEvent) error
+
+//Webhook : http.Handler receiving OVO callback notifications
+type Webhook struct {
+    Verifier *Verifier
+
+    mm               *MatahariMall
+    mu               sync.RWMutex
+    onTransaction    []TransactionHandler
+    onAuthentication []AuthenticationHandler
+}
+
+//LinkageEvent : Change of a customer linkage, Before is nil for a new linkage
+type LinkageEvent struct {
+    CustomerID int64
+    Before     *CustomerOvo
+    After      *CustomerOvo
+    At         time.Time
+}
+
+//LinkageSubscriber : Receive customer linkage lifecycle events
+type LinkageSubscriber interface {
+    //OnAuthenticationSent : OVO pushed the security code screen to the customer
+    OnAuthenticationSent(LinkageEvent)
+
+    //OnLinkageVerified : Customer finished the authentication, linkage is verified
+    OnLinkageVerified(LinkageEvent)
+
+    //OnLinkageRejected : Customer rejected the authentication or OVO no longer knows it or the customer, a pending authentication is not rejected
+    OnLinkageRejected(LinkageEvent)
+
+    //OnPhoneChanged : Phone of an unverified linkage has been changed
+    OnPhoneChanged(LinkageEvent)
+}
+
+//NopLinkageSubscriber : LinkageSubscriber ignoring every event, embed it to implement only some of the events
+type NopLinkageSubscriber struct{}
+
+//AsyncSubscriber : LinkageSubscriber delivering events to another subscriber from a background goroutine
+type AsyncSubscriber struct {
+    next   LinkageSubscriber
+    events chan func()
+    done   chan struct{}
+    once   sync.Once
+}
+
+//UnlinkReason : Reason code of a removed linkage
+type UnlinkReason string
+
+//pendingRelink : New phone of a verified linkage waiting for the customer to approve its authentication
+type pendingRelink struct {
+    CustomerID int64
+    OvoPhone   string
+    OvoAuthID  string
+    Reason     UnlinkReason
+    Actor      string
+    Source     string
+}
+
+//HistoryAction : Mutation recorded in customer_ovo_history
+type HistoryAction string
+
+//CustomerOvoHistory : Type for struct name and field the same as customer_ovo_history table
+type CustomerOvoHistory struct {
+    ID            int64
+    CustomerID    int64
+    Action        HistoryAction
+    OldOvoID      string
+    NewOvoID      string
+    OldOvoPhone   string
+    NewOvoPhone   string
+    OldOvoAuthID  string
+    NewOvoAuthID  string
+    OldFgVerified int
+    NewFgVerified int
+    Actor         string
+    Source        string
+    Reason        string
+    CreatedAt     *time.Time
+}
+
+//execer : Either *sql.DB or *sql.Tx
+type execer interface {
+    Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+//BackfillResult : Outcome of BackfillPhoneLookup
+type BackfillResult struct {
+    Updated int
+
+    //Invalid : Customer id of rows with a phone that cannot be normalized
+    Invalid []int64
+}
+
+//SyncResult : Outcome of SyncProfiles
+type SyncResult struct {
+    Synced int
+
+    //Disabled : Customer id of linkages OVO reports as disabled
+    Disabled []int64
+
+    //Failed : Customer id of linkages whose profile could not be read, they are synced again on the next run
+    Failed []int64
+}
+
+//KeyProvider : Keys of the phone encryption, previous keys stay available while a key is being rotated
+type KeyProvider interface {
+    //CurrentKey : Id and AES key (16, 24 or 32 bytes) encrypting new values, the id must not contain ':'
+    CurrentKey() (string, []byte, error)
+
+    //Key : AES key of id, to decrypt values written with a previous key
+    Key(id string) ([]byte, error)
+}
+
+//StaticKeyProvider : KeyProvider from fixed keys
+type StaticKeyProvider struct {
+    Current string
+    Keys    map[string][]byte
+}
+
+//PhoneCipher : Encrypt phones with AES-GCM and compute the blind index stored in ovo_phone_lookup
+type PhoneCipher struct {
+    Keys KeyProvider
+
+    //IndexKey : HMAC-SHA256 key of the blind index, lookups of existing rows miss once it changes
+    IndexKey []byte
+}
+
+//Logger : Leveled logger taking key value pairs after the message, *slog.Logger satisfies it
+type Logger interface {
+    Debug(msg string, args ...interface{})
+    Info(msg string, args ...interface{})
+    Warn(msg string, args ...interface{})
+    Error(msg string, args ...interface{})
+}
+
+type nopLogger struct{}
+
+//redactLogger : Logger masking phone numbers and removing secrets before calling next
+type redactLogger struct {
+    next   Logger
+    client *Client
+}
+
+//FunnelStep : Step of the customer linkage funnel
+type FunnelStep string
+
+//Metrics : Record OVO calls and linkage funnel, implementations must be safe for concurrent use
+type Metrics interface {
+    //ObserveRequest : One OVO call, status is 0 without response and code is 0 when the body has none
+    ObserveRequest(endpoint, method string, status, code int, duration time.Duration)
+
+    //IncLinkage : One customer reaching a funnel step
+    IncLinkage(step FunnelStep)
+}
+
+type nopMetrics struct{}
+
+//PrometheusMetrics : In-memory Metrics exposed in Prometheus text format, serve it as the scrape endpoint
+type PrometheusMetrics struct {
+    mu        sync.Mutex
+    buckets   []float64
+    requests  map[requestKey]uint64
+    latencies map[string]*histogram
+    linkages  map[FunnelStep]uint64
+}
+
+type requestKey struct {
+    endpoint string
+    method   string
+    status   int
+    code     int
+}
+
+type histogram struct {
+    counts []uint64
+    count  uint64
+    sum    float64
+}
+
+//Attribute : Key value describing a span
+type Attribute struct {
+    Key   string
+    Value interface{}
+}
+
+//Tracer : Start spans around OVO calls and SQL statements
+type Tracer interface {
+    //Start : Start span as a child of the span in ctx
+    Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+
+    //Inject : Write the span context of ctx into the headers sent to OVO
+    Inject(ctx context.Context, header http.Header)
+}
+
+//Span : Operation being traced
+type Span interface {
+    SetAttributes(attrs ...Attribute)
+
+    //RecordError : Record err and mark the span as failed
+    RecordError(err error)
+    End()
+}
+
+type nopSpan struct{}
+
+//Doer : Send OVO request, *http.Client satisfies it
+type Doer interface {
+    Do(req *http.Request) (*http.Response, error)
+}
+
+//DoerFunc : Function adapter for Doer
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+//Middleware : Wrap the Doer sending OVO requests
+type Middleware func(next Doer) Doer
+
+//Endpoint : OVO api endpoint, path params are written as :name
+type Endpoint struct {
+    Name   string
+    Method string
+    Path   string
+
+    //SuccessStatus : HTTP status OVO answers when the call succeeds, http.StatusOK when zero
+    SuccessStatus int
+
+    //ParamPatterns : Regex each path param value must match, keyed by param name.
+    //Params without pattern use PathParamPatterns then DefaultPathParamPattern
+    ParamPatterns map[string]string
+
+    params   []string
+    patterns map[string]*regexp.Regexp
+}
+
+//PathParamError : Path param value missing, unknown or not matching its pattern
+type PathParamError struct {
+    Endpoint string
+    Param    string
+    Value    string
+}
+
+//Environment : Named OVO environment, EnvSandbox, EnvStaging or EnvProduction
+type Environment string
+
+//environmentProfile : Client defaults of an Environment
+type environmentProfile struct {
+    BaseURLVar string
+    Timeout    time.Duration
+    LocaleID   string
+}
+
+//Config : Client configuration, empty base url, locale and timeout take the defaults of the Environment
+type Config struct {
+    Environment Environment `json:"environment"`
+    //BaseURL : Base url of the client, overrides the one of the Environment in BaseURLs
+    BaseURL string `json:"base_url"`
+    //BaseURLs : Base url given by OVO for each Environment
+    BaseURLs   map[Environment]string `json:"base_urls"`
+    APIKey     string                 `json:"api_key"`
+    AppID      string                 `json:"app_id"`
+    MerchantID string                 `json:"merchant_id"`
+    LocaleID   string                 `json:"locale"`
+    Timeout    time.Duration          `json:"-"`
+}
+
+//ConfigError : Invalid Config field
+type ConfigError struct {
+    Field  string
+    Reason string
+}
+
+//ClientPool : Clients of several merchants sharing one http transport, resolved by merchant or store key
+type ClientPool struct {
+    //LocaleID : Locale of errors not bound to a merchant, e.g. an unknown merchant key
+    LocaleID string
+
+    mu        sync.RWMutex
+    transport http.RoundTripper
+    clients   map[string]*Client
+    stores    map[string]string
+}
+
+//Credentials : App id and api keys signing OVO requests
+type Credentials struct {
+    AppID  string `json:"app_id"`
+    APIKey string `json:"api_key"`
+
+    //PreviousAPIKey : Key replaced by APIKey, still signing until RotateAt and accepted by CredentialKeys while set
+    PreviousAPIKey string `json:"previous_api_key"`
+
+    //RotateAt : Time requests start being signed with APIKey, zero signs with APIKey right away
+    RotateAt time.Time `json:"rotate_at"`
+}
+
+//CredentialProvider : Credentials consulted every time a request is signed
+type CredentialProvider interface {
+    Credentials() (Credentials, error)
+}
+
+//CredentialProviderFunc : Function adapter for CredentialProvider
+type CredentialProviderFunc func() (Credentials, error)
+
+//FileCredentialProvider : CredentialProvider reading a json Credentials file, reloaded when the file changes
+type FileCredentialProvider struct {
+    path string
+
+    mu      sync.RWMutex
+    creds   Credentials
+    modTime time.Time
+    err     error
+
+    done chan struct{}
+    once sync.Once
+}
+
+//CachedProfile : Customer profile response stored in a ProfileCache
+type CachedProfile struct {
+    Data     []byte
+    StoredAt time.Time
+}
+
+//ProfileCache : Store of customer profile responses, implement it over a shared cache to share profiles between processes.
+//Keys are scoped by the app id and base url of the Client, so one cache can serve several clients
+type ProfileCache interface {
+    Get(key string) (CachedProfile, bool)
+    Set(key string, p CachedProfile)
+    Delete(key string)
+}
+
+//LRUProfileCache : In-memory ProfileCache evicting the least recently used profile
+type LRUProfileCache struct {
+    mu      sync.Mutex
+    size    int
+    order   *list.List
+    entries map[string]*list.Element
+}
+
+type lruEntry struct {
+    key     string
+    profile CachedProfile
+}
+
+//flightGroup : In-flight GET requests keyed by url, concurrent identical requests wait for the first one
+type flightGroup struct {
+    mu    sync.Mutex
+    calls map[string]*flightCall
+}
+
+type flightCall struct {
+    done chan struct{}
+    data []byte
+    err  error
+    dups int
+}
+
+//detachedContext : Values of the parent context without its cancellation, runs a request shared by several callers
+type detachedContext struct {
+    parent context.Context
+}
+
+//RateLimiter : Limit the rate of requests sent to OVO
+type RateLimiter interface {
+    //Wait : Block until a request may be sent or ctx is done
+    Wait(ctx context.Context) error
+}
+
+//TokenBucket : RateLimiter allowing rate requests per second with bursts of burst requests
+type TokenBucket struct {
+    mu     sync.Mutex
+    rate   float64
+    burst  float64
+    tokens float64
+    last   time.Time
+}
+
+//ProfileResult : Customer profile looked up by GetCustomerProfiles
+type ProfileResult struct {
+    //Index : Position of the customer id in the slice, or in the order it was received from the channel
+    Index      int
+    CustomerID string
+    Profile    ResponseData
+
+    //Data : Raw OVO response, also set when Err comes from OVO
+    Data []byte
+    Err  error
 }
@@ -0,0 +1,338 @@
+package ovo
+
+import (
+    "database/sql"
+    "net/http"
+    "strings"
+    "time"
+)
+
+//UnlinkCustomer : Remove customer linkage, verified or not, and keep an audit row with the reason and actor.
+//The unlinked phone can be linked again by the same customer right away, by another customer after UnlinkCooldown
+func (c *MatahariMall) UnlinkCustomer(customerID int64, reason UnlinkReason, actor string) error {
+    if !unlinkReasons[reason] {
+        return TErr("ovo_invalid_unlink_reason", c.API.LocaleID)
+    }
+
+    cOvo, err := c.queryCustomerOvo("customer_id=?", customerID)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return TErr("ovo_not_linked", c.API.LocaleID)
+        }
+        return wrapErr(err, ErrCategoryTransport)
+    }
+
+    tx, err := c.DB.Begin()
+    if err != nil {
+        return wrapErr(err, ErrCategoryTransport)
+    }
+
+    err = c.unlink(tx, cOvo, reason, actor)
+    if err != nil {
+        tx.Rollback()
+        return err
+    }
+
+    if err = tx.Commit(); err != nil {
+        return wrapErr(err, ErrCategoryTransport)
+    }
+    c.invalidateProfiles(&cOvo)
+
+    if c.OvoInfo != nil && c.OvoInfo.CustomerID == customerID {
+        c.OvoInfo = nil
+        c.stored = nil
+    }
+
+    return nil
+}
+
+//unlink : Move the linkage to customer_ovo_unlink within tx
+func (c *MatahariMall) unlink(tx execer, cOvo CustomerOvo, reason UnlinkReason, actor string) error {
+    sealedPhone, err := c.sealPhone(cOvo.OvoPhone)
+    if err != nil {
+        return err
+    }
+
+    sqlInsert := `INSERT INTO
+                    customer_ovo_unlink(
+                        customer_id,
+                        ovo_id,
+                        ovo_phone,
+                        ovo_phone_lookup,
+                        ovo_auth_id,
+                        fg_verified,
+                        reason,
+                        source,
+                        unlinked_at
+                    )
+                  VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW())`
+    end := c.sqlSpan("INSERT", "customer_ovo_unlink")
+    _, err = tx.Exec(sqlInsert, cOvo.CustomerID, nullString(cOvo.OvoID), sealedPhone, nullString(c.phoneLookup(cOvo.OvoPhone)), cOvo.OvoAuthID, cOvo.FgVerified, string(reason), c.API.AppID)
+    end(err)
+    if err != nil {
+        return wrapErr(err, ErrCategoryTransport)
+    }
+
+    end = c.sqlSpan("DELETE", "customer_ovo")
+    _, err = tx.Exec(`DELETE FROM customer_ovo WHERE customer_id = ?`, cOvo.CustomerID)
+    end(err)
+    if err != nil {
+        return wrapErr(err, ErrCategoryTransport)
+    }
+
+    return c.addHistory(tx, HistoryUnlink, &cOvo, nil, actor, string(reason))
+}
+
+//RelinkCustomer : Move the linkage of the customer to the ovoReq phone, the current linkage is unlinked with reason.
+//A verified linkage stays in place while the customer has to approve the authentication of the new phone, it is
+//replaced once CheckRelinkStatus or the webhook finds the authentication approved. A linkage not verified yet is
+//replaced right away like ValidateOvoIDAndAuthenticateToOvo does
+func (c *MatahariMall) RelinkCustomer(ovoReq *Request, reason UnlinkReason) error {
+    if !unlinkReasons[reason] {
+        return TErr("ovo_invalid_unlink_reason", c.API.LocaleID)
+    }
+    if err := c.parsePhoneNumber(ovoReq); err != nil {
+        return err
+    }
+
+    current, err := c.queryCustomerOvo("customer_id=?", ovoReq.CustomerID)
+    if err != nil && err != sql.ErrNoRows {
+        return wrapErr(err, ErrCategoryTransport)
+    }
+    if err == sql.ErrNoRows || current.FgVerified <= 0 {
+        return c.ValidateOvoIDAndAuthenticateToOvo(ovoReq)
+    }
+
+    if current.OvoPhone == ovoReq.Phone {
+        return TErr("ovo_already_verified", c.API.LocaleID)
+    }
+    cuid, _, _ := c.getCustomerOvoByPhone(ovoReq.Phone)
+    if cuid > 0 && cuid != ovoReq.CustomerID {
+        c.funnel(FunnelIDUsed)
+        return TErr("ovo_id_used", c.API.LocaleID)
+    }
+    err = c.checkUnlinkCooldown(ovoReq)
+    if err != nil {
+        return err
+    }
+
+    authID, err := c.sendAuthentication(ovoReq)
+    if err != nil {
+        return err
+    }
+
+    sealedPhone, err := c.sealPhone(ovoReq.Phone)
+    if err != nil {
+        return err
+    }
+
+    sqlReplace := `REPLACE INTO
+                    customer_ovo_relink(
+                        customer_id,
+                        ovo_phone,
+                        ovo_phone_lookup,
+                        ovo_auth_id,
+                        reason,
+                        actor,
+                        source,
+                        created_at
+                    )
+                  VALUES (?, ?, ?, ?, ?, ?, ?, NOW())`
+    end := c.sqlSpan("REPLACE", "customer_ovo_relink")
+    _, err = c.DB.Exec(sqlReplace, ovoReq.CustomerID, sealedPhone, nullString(c.phoneLookup(ovoReq.Phone)), authID, string(reason), ovoReq.Actor, c.API.AppID)
+    end(err)
+    if err != nil {
+        return wrapErr(err, ErrCategoryTransport)
+    }
+
+    c.funnel(FunnelAuthSent)
+    return nil
+}
+
+//CheckRelinkStatus : Check the authentication sent by RelinkCustomer, the linkage is replaced once OVO reports it approved.
+//Return ovo_retry_verification while it is not approved, the pending relink is dropped when the customer rejected it
+func (c *MatahariMall) CheckRelinkStatus(customerID int64) (*CustomerOvo, error) {
+    p, err := c.queryRelink("customer_id=?", customerID)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, TErr("ovo_not_authenticated", c.API.LocaleID)
+        }
+        return nil, wrapErr(err, ErrCategoryTransport)
+    }
+
+    r, err := c.authenticationStatus(p.OvoAuthID)
+    if err != nil {
+        return nil, err
+    }
+    if r.Status == http.StatusOK && r.Code == Authenticated && r.Data.LoyaltyID != "" {
+        return c.completeRelink(p, r.Data.LoyaltyID)
+    }
+
+    c.log().Info("ovo relink not verified", "endpoint", "customer_authentication_status", "customer_id", customerID, "status", r.Status, "code", r.Code)
+    if authenticationRejected(r) {
+        if err := c.dropRelink(p); err != nil {
+            return nil, err
+        }
+    }
+    return nil, TErr("ovo_retry_verification", c.API.LocaleID)
+}
+
+func (c *MatahariMall) queryRelink(where string, arg interface{}) (pendingRelink, error) {
+    var p pendingRelink
+    var reason string
+
+    q := `SELECT customer_id,
+                   ovo_phone,
+                   ovo_auth_id,
+                   reason,
+                   actor,
+                   source
+            FROM customer_ovo_relink
+            WHERE ` + where
+
+    end := c.sqlSpan("SELECT", "customer_ovo_relink")
+    err := c.DB.QueryRow(q, arg).Scan(
+        &p.CustomerID,
+        &p.OvoPhone,
+        &p.OvoAuthID,
+        &reason,
+        &p.Actor,
+        &p.Source,
+    )
+    end(err)
+
+    p.Reason = UnlinkReason(reason)
+    if err == nil {
+        p.OvoPhone, err = c.openPhone(p.OvoPhone)
+    }
+
+    return p, err
+}
+
+//completeRelink : Unlink the current linkage and store the approved one of p in a single transaction
+func (c *MatahariMall) completeRelink(p pendingRelink, loyaltyID string) (*CustomerOvo, error) {
+    previous, err := c.queryCustomerOvo("customer_id=?", p.CustomerID)
+    if err != nil && err != sql.ErrNoRows {
+        return nil, wrapErr(err, ErrCategoryTransport)
+    }
+    linked := err == nil
+
+    next := CustomerOvo{
+        CustomerID: p.CustomerID,
+        OvoID:      loyaltyID,
+        OvoPhone:   p.OvoPhone,
+        OvoAuthID:  p.OvoAuthID,
+        FgVerified: 1,
+        Source:     p.Source,
+    }
+    sealedPhone, err := c.sealPhone(next.OvoPhone)
+    if err != nil {
+        return nil, err
+    }
+
+    tx, err := c.DB.Begin()
+    if err != nil {
+        return nil, wrapErr(err, ErrCategoryTransport)
+    }
+
+    if linked {
+        err = c.unlink(tx, previous, p.Reason, p.Actor)
+        if err != nil {
+            tx.Rollback()
+            return nil, err
+        }
+    }
+
+    sqlInsert := `INSERT INTO
+                    customer_ovo(
+                        customer_id,
+                        ovo_id,
+                        ovo_phone,
+                        ovo_phone_lookup,
+                        ovo_auth_id,
+                        fg_verified,
+                        created_at,
+                        updated_at,
+                        source
+                    )
+                  VALUES (?, ?, ?, ?, ?, 1, NOW(), NOW(), ?)`
+    end := c.sqlSpan("INSERT", "customer_ovo")
+    _, err = tx.Exec(sqlInsert, next.CustomerID, next.OvoID, sealedPhone, nullString(c.phoneLookup(next.OvoPhone)), next.OvoAuthID, next.Source)
+    end(err)
+    if err != nil {
+        tx.Rollback()
+        if strings.Contains(err.Error(), "1062") {
+            c.funnel(FunnelIDUsed)
+            return nil, causeErr("ovo_id_used", c.API.LocaleID, err)
+        }
+        return nil, wrapErr(err, ErrCategoryTransport)
+    }
+
+    end = c.sqlSpan("DELETE", "customer_ovo_relink")
+    _, err = tx.Exec(`DELETE FROM customer_ovo_relink WHERE customer_id = ?`, p.CustomerID)
+    end(err)
+    if err != nil {
+        tx.Rollback()
+        return nil, wrapErr(err, ErrCategoryTransport)
+    }
+
+    err = c.addHistory(tx, HistoryInsert, nil, &next, p.Actor, string(p.Reason))
+    if err != nil {
+        tx.Rollback()
+        return nil, err
+    }
+
+    if err = tx.Commit(); err != nil {
+        return nil, wrapErr(err, ErrCategoryTransport)
+    }
+    if linked {
+        c.invalidateProfiles(&previous)
+    }
+
+    c.log().Info("ovo linkage relinked", "customer_id", next.CustomerID, "phone", next.OvoPhone)
+    c.funnel(FunnelVerified)
+    c.publish(LinkageSubscriber.OnLinkageVerified, snapshot(&previous), snapshot(&next))
+    return &next, nil
+}
+
+//dropRelink : Forget the relink rejected by the customer, the current linkage stays
+func (c *MatahariMall) dropRelink(p pendingRelink) error {
+    end := c.sqlSpan("DELETE", "customer_ovo_relink")
+    _, err := c.DB.Exec(`DELETE FROM customer_ovo_relink WHERE customer_id = ? AND ovo_auth_id = ?`, p.CustomerID, p.OvoAuthID)
+    end(err)
+    if err != nil {
+        return wrapErr(err, ErrCategoryTransport)
+    }
+
+    c.log().Info("ovo relink rejected", "customer_id", p.CustomerID)
+    c.funnel(FunnelRejected)
+    return nil
+}
+
+//checkUnlinkCooldown : Stop linking a phone unlinked from another customer less than UnlinkCooldown ago
+func (c *MatahariMall) checkUnlinkCooldown(ovoReq *Request) error {
+    if c.UnlinkCooldown <= 0 {
+        return nil
+    }
+
+    var customerID int64
+    q := `SELECT customer_id
+            FROM customer_ovo_unlink
+           WHERE ovo_phone_lookup = ?
+             AND customer_id != ?
+             AND unlinked_at > NOW() - INTERVAL ? SECOND
+           LIMIT 1`
+
+    //unlinked_at is written with the database clock, compare it there too
+    end := c.sqlSpan("SELECT", "customer_ovo_unlink")
+    err := c.DB.QueryRow(q, c.phoneLookup(ovoReq.Phone), ovoReq.CustomerID, int64(c.UnlinkCooldown/time.Second)).Scan(&customerID)
+    end(err)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil
+        }
+        return wrapErr(err, ErrCategoryTransport)
+    }
+
+    return TErr("ovo_phone_cooldown", c.API.LocaleID)
+}
@@ -0,0 +1,188 @@
+package ovo
+
+import (
+    "database/sql"
+    "net/http"
+    "testing"
+
+    sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestUnlinkCustomer(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, "6789", "081208080808", "123", 1, "hypermart")
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnRows(rows)
+    mock.ExpectBegin()
+    mock.ExpectExec(`INSERT INTO customer_ovo_unlink`).WithArgs(12345, "6789", "081208080808", "+6281208080808", "123", 1, "phone_lost", "hypermart").WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectExec(`DELETE FROM customer_ovo`).WithArgs(12345).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WithArgs(12345, "unlink", "6789", "", "081208080808", "", "123", "", 1, 0, "cs:andi", "hypermart", "phone_lost").WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    client := new(Client)
+    client.LocaleID = "en"
+    client.AppID = "hypermart"
+    mmsdk := client.GetMMsdk(db)
+
+    if err := mmsdk.UnlinkCustomer(12345, UnlinkPhoneLost, "cs:andi"); err != nil {
+        t.Errorf("This should not error, got %s", err)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("This should delete linkage and insert audit row: %s", err)
+    }
+}
+
+func TestUnlinkCustomerInvalid(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    mock.ExpectQuery(`SELECT customer_id`).WillReturnError(sql.ErrNoRows)
+
+    client := new(Client)
+    client.LocaleID = "en"
+    mmsdk := client.GetMMsdk(db)
+
+    err = mmsdk.UnlinkCustomer(12345, UnlinkReason("bored"), "")
+    if err == nil || err.Error() != TErr("ovo_invalid_unlink_reason", client.LocaleID).Error() {
+        t.Errorf("This should return Err: %s", TErr("ovo_invalid_unlink_reason", client.LocaleID).Error())
+    }
+
+    err = mmsdk.UnlinkCustomer(12345, UnlinkAccountClosed, "")
+    if err == nil || err.Error() != TErr("ovo_not_linked", client.LocaleID).Error() {
+        t.Errorf("This should return Err: %s", TErr("ovo_not_linked", client.LocaleID).Error())
+    }
+}
+
+func TestValidateOvoIDAndAuthenticateToOvoCooldown(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnError(sql.ErrNoRows)
+    mock.ExpectQuery(`SELECT customer_id, fg_verified FROM customer_ovo`).WillReturnError(sql.ErrNoRows)
+    mock.ExpectQuery(`SELECT customer_id FROM customer_ovo_unlink`).WithArgs("+6281208080808", 12345, int64(30*24*3600)).WillReturnRows(sqlmock.NewRows([]string{"customer_id"}).AddRow(999))
+
+    client := new(Client)
+    client.LocaleID = "en"
+    mmsdk := client.GetMMsdk(db)
+
+    err = mmsdk.ValidateOvoIDAndAuthenticateToOvo(&Request{CustomerID: 12345, Phone: "081208080808"})
+    if err == nil || err.Error() != TErr("ovo_phone_cooldown", client.LocaleID).Error() {
+        t.Errorf("This should return Err: %s", TErr("ovo_phone_cooldown", client.LocaleID).Error())
+    }
+    if GetErrCategory(err) != ErrCategoryConflict {
+        t.Errorf("Cool-down should be a conflict error")
+    }
+}
+
+func TestRelinkCustomer(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    linked := func() {
+        rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, "6789", "081208080808", "123", 1, "foodmart")
+        mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnRows(rows)
+        mock.ExpectQuery(`SELECT customer_id, fg_verified FROM customer_ovo`).WillReturnError(sql.ErrNoRows)
+        mock.ExpectQuery(`SELECT customer_id FROM customer_ovo_unlink`).WillReturnError(sql.ErrNoRows)
+    }
+
+    linked()
+    mock.ExpectExec(`REPLACE INTO customer_ovo_relink`).WithArgs(12345, "081909090909", "+6281909090909", "666", "phone_lost", "cs:andi", "hypermart").WillReturnResult(sqlmock.NewResult(0, 1))
+    linked()
+
+    status := http.StatusCreated
+    client := new(Client)
+    client.LocaleID = "en"
+    client.AppID = "hypermart"
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(status)
+        w.Write([]byte(`{"status": 201, "data": {"authentication_id": "666"}, "message": "Success", "code": 1}`))
+    }
+    mmsdk := client.GetMMsdk(db)
+
+    err = mmsdk.RelinkCustomer(&Request{CustomerID: 12345, Phone: "081909090909", Actor: "cs:andi"}, UnlinkPhoneLost)
+    if err != nil {
+        t.Errorf("This should not error, got %s", err)
+    }
+
+    status = http.StatusServiceUnavailable
+    err = mmsdk.RelinkCustomer(&Request{CustomerID: 12345, Phone: "081909090909", Actor: "cs:andi"}, UnlinkPhoneLost)
+    if err == nil || !IsTemporary(err) {
+        t.Errorf("Relink should return the authentication error, got %v", err)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("Verified linkage should be kept until the new phone is approved: %s", err)
+    }
+}
+
+func TestCheckRelinkStatus(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    relink := func() {
+        rows := sqlmock.NewRows([]string{"customer_id", "ovo_phone", "ovo_auth_id", "reason", "actor", "source"}).AddRow(12345, "081909090909", "666", "phone_lost", "cs:andi", "hypermart")
+        mock.ExpectQuery(`SELECT customer_id, ovo_phone, ovo_auth_id, reason, actor, source FROM customer_ovo_relink WHERE customer_id`).WithArgs(12345).WillReturnRows(rows)
+    }
+
+    relink()
+    mock.ExpectExec(`DELETE FROM customer_ovo_relink WHERE customer_id = \? AND ovo_auth_id = \?`).WithArgs(12345, "666").WillReturnResult(sqlmock.NewResult(0, 1))
+
+    relink()
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, "6789", "081208080808", "123", 1, "foodmart")
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified`).WillReturnRows(rows)
+    mock.ExpectBegin()
+    mock.ExpectExec(`INSERT INTO customer_ovo_unlink`).WithArgs(12345, "6789", "081208080808", "+6281208080808", "123", 1, "phone_lost", "hypermart").WillReturnResult(sqlmock.NewResult(7, 1))
+    mock.ExpectExec(`DELETE FROM customer_ovo`).WithArgs(12345).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WithArgs(12345, "unlink", "6789", "", "081208080808", "", "123", "", 1, 0, "cs:andi", "hypermart", "phone_lost").WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo\(`).WithArgs(12345, "8000000000000666", "081909090909", "+6281909090909", "666", "hypermart").WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectExec(`DELETE FROM customer_ovo_relink WHERE customer_id = \?`).WithArgs(12345).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WithArgs(12345, "insert", "", "8000000000000666", "", "081909090909", "", "666", 0, 1, "cs:andi", "hypermart", "phone_lost").WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    var response string
+    client := new(Client)
+    client.LocaleID = "en"
+    client.AppID = "hypermart"
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(response))
+    }
+    mmsdk := client.GetMMsdk(db)
+    sub := &recordSubscriber{}
+    mmsdk.Subscribe(sub)
+
+    response = `{"status": 200, "data": [], "message": "Rejected by customer", "code": 2}`
+    _, err = mmsdk.CheckRelinkStatus(12345)
+    if err == nil || err.Error() != TErr("ovo_retry_verification", client.LocaleID).Error() {
+        t.Errorf("Rejected relink should ask to retry verification, got %v", err)
+    }
+
+    response = `{"status": 200, "data": {"loyalty_id": "8000000000000666"}, "message": "Authenticated", "code": 1}`
+    info, err := mmsdk.CheckRelinkStatus(12345)
+    if err != nil {
+        t.Fatalf("Approved relink should not error, got %s", err)
+    }
+    if info.OvoID != "8000000000000666" || info.OvoPhone != "081909090909" || info.FgVerified != 1 {
+        t.Errorf("Linkage should be replaced with the approved phone, got %+v", info)
+    }
+    if len(sub.events) != 1 || sub.events[0] != "verified" || sub.last.Before.OvoID != "6789" {
+        t.Errorf("Relinked linkage should be published as verified, got %v", sub.events)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("there were unfulfilled expectations: %s", err)
+    }
+}
@@ -0,0 +1,184 @@
+package ovo
+
+import (
+    "crypto/hmac"
+    "encoding/hex"
+    "net/http"
+    "sync"
+    "time"
+)
+
+//KeyResolver : Resolve the api keys accepted for an app id, more than one while a key is being rotated
+type KeyResolver interface {
+    ResolveKeys(appID string) ([]string, error)
+}
+
+//KeyResolverFunc : Function adapter for KeyResolver
+type KeyResolverFunc func(appID string) ([]string, error)
+
+//ResolveKeys : Call the function
+func (f KeyResolverFunc) ResolveKeys(appID string) ([]string, error) {
+    return f(appID)
+}
+
+//StaticKeys : KeyResolver from a fixed app id to api key map
+type StaticKeys map[string]string
+
+//ResolveKeys : Api key of the app id
+func (k StaticKeys) ResolveKeys(appID string) ([]string, error) {
+    if key, ok := k[appID]; ok {
+        return []string{key}, nil
+    }
+    return nil, nil
+}
+
+//ReplayCache : Remember signatures already accepted until they expire.
+//The random header has a one second resolution, so a client sending two requests within the same second reuses the signature
+type ReplayCache interface {
+    //Seen : Record the nonce, return true when it was already recorded and has not expired
+    Seen(nonce string, expiry time.Time) bool
+
+    //Recorded : Return true when the nonce was recorded and has not expired, without recording it
+    Recorded(nonce string) bool
+}
+
+//MemoryReplayCache : In-memory ReplayCache for a single process
+type MemoryReplayCache struct {
+    mu     sync.Mutex
+    nonces map[string]time.Time
+    sweep  time.Time
+}
+
+//NewMemoryReplayCache : Constructor for MemoryReplayCache
+func NewMemoryReplayCache() *MemoryReplayCache {
+    return &MemoryReplayCache{nonces: map[string]time.Time{}}
+}
+
+//Seen : Record the nonce, return true when it was already recorded and has not expired
+func (c *MemoryReplayCache) Seen(nonce string, expiry time.Time) bool {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    now := time.Now()
+    if now.After(c.sweep) {
+        for k, v := range c.nonces {
+            if now.After(v) {
+                delete(c.nonces, k)
+            }
+        }
+        c.sweep = now.Add(time.Minute)
+    }
+
+    if v, ok := c.nonces[nonce]; ok && now.Before(v) {
+        return true
+    }
+    c.nonces[nonce] = expiry
+    return false
+}
+
+//Verifier : Verify app-id / random / hmac headers of requests signed the same way as Client
+type Verifier struct {
+    Keys KeyResolver
+
+    //MaxSkew : Accepted difference between the random timestamp and now, 0 disables the check
+    MaxSkew time.Duration
+
+    //Location : Time zone of the random timestamp, time.Local when nil
+    Location *time.Location
+
+    //Replay : Reject signatures seen before, nil disables the check
+    Replay ReplayCache
+
+    LocaleID string
+    Now      func() time.Time
+}
+
+//Recorded : Return true when the nonce was recorded and has not expired
+func (c *MemoryReplayCache) Recorded(nonce string) bool {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    v, ok := c.nonces[nonce]
+    return ok && time.Now().Before(v)
+}
+
+//VerifyRequest : Verify request signature with keys, random must not be older than DefaultMaxSkew
+func VerifyRequest(r *http.Request, keys KeyResolver) error {
+    v := &Verifier{Keys: keys, MaxSkew: DefaultMaxSkew}
+    return v.Verify(r)
+}
+
+//Verify : Verify request signature against the keys of its app id
+func (v *Verifier) Verify(r *http.Request) error {
+    nonce, expiry, err := v.verifySignature(r)
+    if err != nil {
+        return err
+    }
+
+    //random has a one second resolution, a second legitimate request of the app id within the same
+    //second carries the same signature and is rejected as replayed
+    if v.Replay != nil && v.Replay.Seen(nonce, expiry) {
+        return TErr("ovo_replayed_request", v.locale())
+    }
+
+    return nil
+}
+
+func (v *Verifier) locale() string {
+    if v.LocaleID == "" {
+        return "en"
+    }
+    return v.LocaleID
+}
+
+//verifySignature : Check signature and age of the request, return the nonce of the signature and until when it is valid
+func (v *Verifier) verifySignature(r *http.Request) (string, time.Time, error) {
+    locale := v.locale()
+    var expiry time.Time
+
+    appID := r.Header.Get("app-id")
+    random := r.Header.Get("random")
+    mac, err := hex.DecodeString(r.Header.Get("hmac"))
+    if appID == "" || random == "" || err != nil || len(mac) == 0 {
+        return "", expiry, TErr("ovo_invalid_signature", locale)
+    }
+
+    keys, err := v.Keys.ResolveKeys(appID)
+    if err != nil {
+        return "", expiry, causeErr("ovo_invalid_signature", locale, err)
+    }
+
+    valid := false
+    for _, key := range keys {
+        expected, _ := hex.DecodeString(signature(appID, random, key))
+        if hmac.Equal(expected, mac) {
+            valid = true
+        }
+    }
+    if !valid {
+        return "", expiry, TErr("ovo_invalid_signature", locale)
+    }
+
+    now := time.Now()
+    if v.Now != nil {
+        now = v.Now()
+    }
+
+    expiry = now.Add(DefaultMaxSkew)
+    if v.MaxSkew > 0 {
+        loc := v.Location
+        if loc == nil {
+            loc = time.Local
+        }
+        ts, err := time.ParseInLocation(RandomLayout, random, loc)
+        if err != nil {
+            return "", expiry, TErr("ovo_invalid_signature", locale)
+        }
+        if ts.Before(now.Add(-v.MaxSkew)) || ts.After(now.Add(v.MaxSkew)) {
+            return "", expiry, TErr("ovo_expired_signature", locale)
+        }
+        expiry = ts.Add(v.MaxSkew)
+    }
+
+    return appID + ":" + random + ":" + hex.EncodeToString(mac), expiry, nil
+}
@@ -0,0 +1,75 @@
+package ovo
+
+import (
+    "testing"
+    "time"
+)
+
+func TestVerifyRequest(t *testing.T) {
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    req, err := client.newRequest("GET", "http://testing.com/customers/1", nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if err := VerifyRequest(req, StaticKeys{"hypermart": "secret"}); err != nil {
+        t.Errorf("Signed request should be valid, got %s", err)
+    }
+
+    err = VerifyRequest(req, StaticKeys{"hypermart": "other"})
+    if err == nil || err.Error() != TErr("ovo_invalid_signature", "en").Error() {
+        t.Errorf("Request signed with other key should be invalid")
+    }
+    if GetErrCategory(err) != ErrCategoryAuth {
+        t.Errorf("Invalid signature should be an auth error")
+    }
+
+    if err := VerifyRequest(req, StaticKeys{"other": "secret"}); err == nil {
+        t.Errorf("Unknown app id should be invalid")
+    }
+}
+
+func TestVerifyRequestRotation(t *testing.T) {
+    client := New("http://testing.com", "old", "hypermart", "1")
+    req, _ := client.newRequest("GET", "http://testing.com/customers/1", nil)
+
+    keys := KeyResolverFunc(func(appID string) ([]string, error) {
+        return []string{"new", "old"}, nil
+    })
+    if err := VerifyRequest(req, keys); err != nil {
+        t.Errorf("Previous key should be accepted during rotation")
+    }
+}
+
+func TestVerifyRequestExpired(t *testing.T) {
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    req, _ := client.newRequest("GET", "http://testing.com/customers/1", nil)
+
+    v := &Verifier{
+        Keys:    StaticKeys{"hypermart": "secret"},
+        MaxSkew: time.Minute,
+        Now:     func() time.Time { return time.Now().Add(10 * time.Minute) },
+    }
+    err := v.Verify(req)
+    if err == nil || err.Error() != TErr("ovo_expired_signature", "en").Error() {
+        t.Errorf("Old random should be expired")
+    }
+}
+
+func TestVerifyRequestReplay(t *testing.T) {
+    client := New("http://testing.com", "secret", "hypermart", "1")
+    req, _ := client.newRequest("GET", "http://testing.com/customers/1", nil)
+
+    v := &Verifier{
+        Keys:    StaticKeys{"hypermart": "secret"},
+        MaxSkew: time.Minute,
+        Replay:  NewMemoryReplayCache(),
+    }
+    if err := v.Verify(req); err != nil {
+        t.Errorf("First request should be valid, got %s", err)
+    }
+    err := v.Verify(req)
+    if err == nil || err.Error() != TErr("ovo_replayed_request", "en").Error() {
+        t.Errorf("Same signature should be rejected")
+    }
+}
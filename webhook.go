@@ -0,0 +1,301 @@
+package ovo
+
+import (
+    "crypto/sha256"
+    "database/sql"
+    "encoding/hex"
+    "encoding/json"
+    "io/ioutil"
+    "net/http"
+)
+
+//NewWebhook : Webhook verifying notifications with keys and updating customer_ovo on authentication callbacks.
+//Set Verifier.Replay to reject a notification already handled, a shared ReplayCache when running several processes.
+//A notification is recorded once its handlers succeed, so OVO can retry a failed one
+func (c *MatahariMall) NewWebhook(keys KeyResolver) *Webhook {
+    return &Webhook{
+        Verifier: &Verifier{Keys: keys, MaxSkew: DefaultMaxSkew, LocaleID: c.API.LocaleID},
+        mm:       c,
+    }
+}
+
+//OnTransaction : Register handler for transaction callbacks
+func (w *Webhook) OnTransaction(h TransactionHandler) {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+
+    w.onTransaction = append(w.onTransaction, h)
+}
+
+//OnAuthentication : Register handler for authentication callbacks
+func (w *Webhook) OnAuthentication(h AuthenticationHandler) {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+
+    w.onAuthentication = append(w.onAuthentication, h)
+}
+
+//ServeHTTP : Verify, decode and dispatch OVO notification
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+    if r.Method != "POST" {
+        writeNotificationResult(rw, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+        return
+    }
+
+    nonce, expiry, err := w.Verifier.verifySignature(r)
+    if err != nil {
+        w.mm.log().Warn("ovo notification rejected", "app_id", r.Header.Get("app-id"), "error", err)
+        writeNotificationResult(rw, http.StatusUnauthorized, err.Error())
+        return
+    }
+
+    body, err := ioutil.ReadAll(http.MaxBytesReader(rw, r.Body, MaxNotificationBytes))
+    if err != nil {
+        writeNotificationResult(rw, http.StatusRequestEntityTooLarge, err.Error())
+        return
+    }
+
+    var n Notification
+    if err := json.Unmarshal(body, &n); err != nil {
+        writeNotificationResult(rw, http.StatusBadRequest, TErr("ovo_invalid_response", w.mm.API.LocaleID).Error())
+        return
+    }
+
+    var dispatch func(Notification) error
+    switch n.Event {
+    case "transaction":
+        dispatch = w.dispatchTransaction
+    case "authentication":
+        dispatch = w.dispatchAuthentication
+    default:
+        writeNotificationResult(rw, http.StatusBadRequest, TErr("ovo_unidentified_request", w.mm.API.LocaleID).Error())
+        return
+    }
+
+    //The signature neither covers the body nor tells apart notifications signed within the same second
+    digest := sha256.Sum256(body)
+    nonce += ":" + hex.EncodeToString(digest[:])
+    replay := w.Verifier.Replay
+    if replay != nil && replay.Recorded(nonce) {
+        err = TErr("ovo_replayed_request", w.Verifier.locale())
+        w.mm.log().Warn("ovo notification rejected", "app_id", r.Header.Get("app-id"), "error", err)
+        writeNotificationResult(rw, http.StatusUnauthorized, err.Error())
+        return
+    }
+
+    if err = dispatch(n); err != nil {
+        w.mm.log().Error("ovo notification failed", "event", n.Event, "code", n.Code, "error", err)
+        writeNotificationResult(rw, http.StatusInternalServerError, err.Error())
+        return
+    }
+    if replay != nil {
+        replay.Seen(nonce, expiry)
+    }
+    writeNotificationResult(rw, http.StatusOK, "OK")
+}
+
+func (w *Webhook) dispatchTransaction(n Notification) error {
+    //The signature does not cover the body, the event carries the transaction as reported by OVO
+    r, err := w.mm.confirmTransaction(n)
+    if err != nil {
+        w.mm.log().Warn("ovo transaction callback not confirmed", "order_id", n.Data.OrderID, "error", err)
+        return err
+    }
+
+    ev := TransactionEvent{
+        Type:            EventTransactionFailed,
+        Code:            r.Code,
+        Message:         r.Message,
+        OrderID:         r.Data.OrderID,
+        MerchantInvoice: r.Data.MerchantInvoice,
+        ApprovalCode:    r.Data.ApprovalCode,
+        CustomerPhone:   r.Data.CustomerPhone,
+        Data:            r.Data,
+    }
+    if r.Code == Success {
+        ev.Type = EventTransactionSuccess
+    }
+
+    w.mu.RLock()
+    handlers := w.onTransaction
+    w.mu.RUnlock()
+
+    for _, h := range handlers {
+        if err := h(ev); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (w *Webhook) dispatchAuthentication(n Notification) error {
+    ev := AuthenticationEvent{
+        Type:             EventAuthenticationRejected,
+        Code:             n.Code,
+        Message:          n.Message,
+        AuthenticationID: n.Data.AuthenticationID,
+        LoyaltyID:        n.Data.LoyaltyID,
+        Phone:            n.Data.Phone,
+        Data:             n.Data,
+    }
+    if n.Code == Authenticated {
+        ev.Type = EventAuthenticationApproved
+    }
+
+    info, err := w.mm.clone().applyAuthentication(&ev)
+    if err != nil {
+        return err
+    }
+    ev.CustomerOvo = info
+
+    w.mu.RLock()
+    handlers := w.onAuthentication
+    w.mu.RUnlock()
+
+    for _, h := range handlers {
+        if err := h(ev); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+//applyAuthentication : Verify the linkage waiting for the authentication, nil when the authentication is unknown.
+//The signature does not cover the body, the approval and loyalty id or the rejection are confirmed with OVO first
+func (c *MatahariMall) applyAuthentication(ev *AuthenticationEvent) (*CustomerOvo, error) {
+    if ev.AuthenticationID == "" {
+        return nil, nil
+    }
+
+    cOvo, err := c.queryCustomerOvo("ovo_auth_id=?", ev.AuthenticationID)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return c.applyRelink(ev)
+        }
+        return nil, wrapErr(err, ErrCategoryTransport)
+    }
+
+    if cOvo.FgVerified > 0 {
+        return &cOvo, nil
+    }
+    if ev.Type != EventAuthenticationApproved {
+        if err := c.confirmRejection(ev.AuthenticationID); err != nil {
+            c.log().Warn("ovo authentication callback not confirmed", "customer_id", cOvo.CustomerID, "error", err)
+            return nil, err
+        }
+        c.funnel(FunnelRejected)
+        c.publish(LinkageSubscriber.OnLinkageRejected, snapshot(&cOvo), snapshot(&cOvo))
+        return &cOvo, nil
+    }
+
+    loyaltyID, err := c.confirmAuthentication(ev.AuthenticationID)
+    if err != nil {
+        c.log().Warn("ovo authentication callback not confirmed", "customer_id", cOvo.CustomerID, "error", err)
+        return nil, err
+    }
+    ev.LoyaltyID = loyaltyID
+
+    before := snapshot(&cOvo)
+    c.OvoInfo = &cOvo
+    c.stored = before
+    c.OvoReq = &Request{
+        CustomerID: cOvo.CustomerID,
+        Phone:      cOvo.OvoPhone,
+        AuthID:     ev.AuthenticationID,
+        Actor:      ActorOVO,
+        Reason:     ReasonAuthenticationCallback,
+    }
+    c.OvoInfo.OvoID = ev.LoyaltyID
+    c.OvoInfo.FgVerified = 1
+
+    if err := c.saveToDatabase(); err != nil {
+        return nil, err
+    }
+    c.linkageChanged(before, snapshot(c.OvoInfo))
+    return c.OvoInfo, nil
+}
+
+//applyRelink : Replace the linkage once the authentication sent by RelinkCustomer is approved, nil when the authentication is unknown
+func (c *MatahariMall) applyRelink(ev *AuthenticationEvent) (*CustomerOvo, error) {
+    p, err := c.queryRelink("ovo_auth_id=?", ev.AuthenticationID)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, nil
+        }
+        return nil, wrapErr(err, ErrCategoryTransport)
+    }
+
+    if ev.Type != EventAuthenticationApproved {
+        if err := c.confirmRejection(ev.AuthenticationID); err != nil {
+            c.log().Warn("ovo relink callback not confirmed", "customer_id", p.CustomerID, "error", err)
+            return nil, err
+        }
+        return nil, c.dropRelink(p)
+    }
+
+    loyaltyID, err := c.confirmAuthentication(ev.AuthenticationID)
+    if err != nil {
+        c.log().Warn("ovo relink callback not confirmed", "customer_id", p.CustomerID, "error", err)
+        return nil, err
+    }
+    ev.LoyaltyID = loyaltyID
+
+    return c.completeRelink(p, loyaltyID)
+}
+
+//confirmRejection : Nil when OVO reports the authentication rejected or unknown
+func (c *MatahariMall) confirmRejection(authID string) error {
+    r, err := c.authenticationStatus(authID)
+    if err != nil {
+        return err
+    }
+    if !authenticationRejected(r) {
+        return TErr("ovo_callback_unconfirmed", c.API.LocaleID)
+    }
+    return nil
+}
+
+//confirmAuthentication : Loyalty id of the authentication when OVO reports it authenticated
+func (c *MatahariMall) confirmAuthentication(authID string) (string, error) {
+    data, err := c.API.CheckCustomerAuthenticationStatus(authID)
+    if err != nil {
+        return "", err
+    }
+    r, err := c.API.getResponse(data)
+    if err != nil {
+        return "", err
+    }
+    if r.Status != http.StatusOK || r.Code != Authenticated || r.Data.LoyaltyID == "" {
+        return "", TErr("ovo_not_authenticated", c.API.LocaleID)
+    }
+    return r.Data.LoyaltyID, nil
+}
+
+//confirmTransaction : Status of the notified transaction at OVO, an error when it does not match the notification
+func (c *MatahariMall) confirmTransaction(n Notification) (Response, error) {
+    if n.Data.OrderID == "" || n.Data.CustomerPhone == "" {
+        return Response{}, TErr("ovo_unidentified_request", c.API.LocaleID)
+    }
+
+    data, err := c.API.CheckTransactionStatus(n.Data.CustomerPhone, n.Data.OrderID)
+    if err != nil {
+        return Response{}, err
+    }
+    r, err := c.API.getResponse(data)
+    if err != nil {
+        return Response{}, err
+    }
+    if r.Code != n.Code || r.Data.OrderID != n.Data.OrderID {
+        return Response{}, TErr("ovo_callback_unconfirmed", c.API.LocaleID)
+    }
+    return r, nil
+}
+
+func writeNotificationResult(rw http.ResponseWriter, status int, message string) {
+    rw.Header().Set("Content-Type", "application/json")
+    rw.WriteHeader(status)
+    json.NewEncoder(rw).Encode(map[string]interface{}{
+        "status":  status,
+        "message": message,
+    })
+}
@@ -0,0 +1,333 @@
+package ovo
+
+import (
+    "bytes"
+    "database/sql"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func newNotification(t *testing.T, apiKey, body string) *http.Request {
+    client := New("http://testing.com", apiKey, "ovo", "1")
+    req, err := client.newRequest("POST", "http://testing.com/callback", bytes.NewBufferString(body))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return req
+}
+
+func TestWebhookAuthenticationApproved(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, nil, "081234567890", "666", 0, "hypermart")
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified, source FROM customer_ovo WHERE ovo_auth_id`).WithArgs("666").WillReturnRows(rows)
+    mock.ExpectBegin()
+    mock.ExpectExec(`UPDATE customer_ovo`).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    client := New("", "", "", "")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`{"status": 200, "data": {"loyalty_id": "8000428048133600"}, "message": "Authenticated", "code": 1}`))
+    }
+    webhook := client.GetMMsdk(db).NewWebhook(StaticKeys{"ovo": "secret"})
+
+    var received AuthenticationEvent
+    webhook.OnAuthentication(func(ev AuthenticationEvent) error {
+        received = ev
+        return nil
+    })
+
+    w := httptest.NewRecorder()
+    webhook.ServeHTTP(w, newNotification(t, "secret", `{"event":"authentication","code":1,"data":{"authentication_id":"666","loyalty_id":"8000000000000666"}}`))
+
+    if w.Code != http.StatusOK {
+        t.Errorf("Notification should be accepted, got %d %s", w.Code, w.Body.String())
+    }
+    if received.Type != EventAuthenticationApproved {
+        t.Errorf("Handler should receive approved authentication")
+    }
+    if received.CustomerOvo == nil || received.CustomerOvo.OvoID != "8000428048133600" || received.CustomerOvo.FgVerified != 1 {
+        t.Errorf("Linkage should be verified with the loyalty id confirmed by OVO")
+    }
+    if received.LoyaltyID != "8000428048133600" {
+        t.Errorf("Handler should receive the loyalty id confirmed by OVO")
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("there were unfulfilled expectations: %s", err)
+    }
+}
+
+const testTransactionStatus = `{"status": 200, "data": {"order_id": "T1", "merchant_invoice": "INV-1", "customer_phone": "081234567890"}, "message": "Transaction rejected", "code": 2}`
+
+func TestWebhookTransaction(t *testing.T) {
+    var path string
+    client := New("", "", "", "")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        path = r.URL.Path
+        w.Write([]byte(testTransactionStatus))
+    }
+    webhook := client.GetMMsdk(nil).NewWebhook(StaticKeys{"ovo": "secret"})
+
+    var received TransactionEvent
+    webhook.OnTransaction(func(ev TransactionEvent) error {
+        received = ev
+        return nil
+    })
+
+    w := httptest.NewRecorder()
+    webhook.ServeHTTP(w, newNotification(t, "secret", `{"event":"transaction","code":2,"data":{"order_id":"T1","merchant_invoice":"INV-forged","customer_phone":"081234567890"}}`))
+
+    if w.Code != http.StatusOK {
+        t.Errorf("Notification should be accepted, got %d", w.Code)
+    }
+    if path != "/customers/081234567890/transactions/T1" {
+        t.Errorf("Transaction should be confirmed with OVO, got %s", path)
+    }
+    if received.Type != EventTransactionFailed || received.MerchantInvoice != "INV-1" {
+        t.Errorf("Handler should receive failed transaction as reported by OVO, got %+v", received)
+    }
+}
+
+func TestWebhookTransactionNotConfirmed(t *testing.T) {
+    client := New("", "", "", "")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(testTransactionStatus))
+    }
+    webhook := client.GetMMsdk(nil).NewWebhook(StaticKeys{"ovo": "secret"})
+
+    called := false
+    webhook.OnTransaction(func(ev TransactionEvent) error {
+        called = true
+        return nil
+    })
+
+    for _, body := range []string{
+        `{"event":"transaction","code":1,"data":{"order_id":"T1","customer_phone":"081234567890"}}`,
+        `{"event":"transaction","code":2}`,
+    } {
+        w := httptest.NewRecorder()
+        webhook.ServeHTTP(w, newNotification(t, "secret", body))
+        if w.Code == http.StatusOK || called {
+            t.Errorf("Transaction not confirmed by OVO should not be dispatched, got %d", w.Code)
+        }
+    }
+}
+
+func TestWebhookInvalidSignature(t *testing.T) {
+    client := New("", "", "", "")
+    webhook := client.GetMMsdk(nil).NewWebhook(StaticKeys{"ovo": "secret"})
+
+    called := false
+    webhook.OnTransaction(func(ev TransactionEvent) error {
+        called = true
+        return nil
+    })
+
+    w := httptest.NewRecorder()
+    webhook.ServeHTTP(w, newNotification(t, "forged", `{"event":"transaction","code":1}`))
+
+    if w.Code != http.StatusUnauthorized {
+        t.Errorf("Forged notification should be rejected, got %d", w.Code)
+    }
+    if called {
+        t.Errorf("Handler must not be called for forged notification")
+    }
+}
+
+func TestWebhookAuthenticationNotConfirmed(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    responses := []string{
+        `{"status": 200, "data": [], "message": "Waiting for customer", "code": 2}`,
+        `{"status": 200, "data": {"loyalty_id": ""}, "message": "Authenticated", "code": 1}`,
+    }
+    for range responses {
+        rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, nil, "081234567890", "666", 0, "hypermart")
+        mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified, source FROM customer_ovo WHERE ovo_auth_id`).WillReturnRows(rows)
+    }
+
+    var response string
+    client := New("", "", "", "")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(response))
+    }
+    webhook := client.GetMMsdk(db).NewWebhook(StaticKeys{"ovo": "secret"})
+
+    for _, response = range responses {
+        w := httptest.NewRecorder()
+        webhook.ServeHTTP(w, newNotification(t, "secret", `{"event":"authentication","code":1,"data":{"authentication_id":"666","loyalty_id":"8000000000000666"}}`))
+        if w.Code == http.StatusOK {
+            t.Errorf("Authentication not confirmed by OVO should not be saved")
+        }
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("there were unfulfilled expectations: %s", err)
+    }
+}
+
+func TestWebhookAuthenticationRejected(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    responses := []string{
+        `{"status": 200, "data": [], "message": "Waiting for customer", "code": 2}`,
+        `{"status": 200, "data": [], "message": "Rejected by customer", "code": 2}`,
+    }
+    for range responses {
+        rows := sqlmock.NewRows([]string{"customer_id", "ovo_id", "ovo_phone", "ovo_auth_id", "fg_verified", "source"}).AddRow(12345, nil, "081234567890", "666", 0, "hypermart")
+        mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified, source FROM customer_ovo WHERE ovo_auth_id`).WillReturnRows(rows)
+    }
+
+    var response string
+    client := New("", "", "", "")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(response))
+    }
+    mmsdk := client.GetMMsdk(db)
+    sub := &recordSubscriber{}
+    mmsdk.Subscribe(sub)
+    webhook := mmsdk.NewWebhook(StaticKeys{"ovo": "secret"})
+
+    var received []AuthenticationEvent
+    webhook.OnAuthentication(func(ev AuthenticationEvent) error {
+        received = append(received, ev)
+        return nil
+    })
+
+    codes := []int{http.StatusInternalServerError, http.StatusOK}
+    for i := range responses {
+        response = responses[i]
+        w := httptest.NewRecorder()
+        webhook.ServeHTTP(w, newNotification(t, "secret", `{"event":"authentication","code":2,"data":{"authentication_id":"666"}}`))
+        if w.Code != codes[i] {
+            t.Errorf("Rejection should be confirmed by OVO, expect %d got %d", codes[i], w.Code)
+        }
+    }
+    if len(received) != 1 || received[0].Type != EventAuthenticationRejected {
+        t.Errorf("Handler should receive the rejection confirmed by OVO, got %+v", received)
+    }
+    if len(sub.events) != 1 || sub.events[0] != "rejected" {
+        t.Errorf("Linkage rejected should be published once confirmed by OVO, got %v", sub.events)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("there were unfulfilled expectations: %s", err)
+    }
+}
+
+func TestWebhookRelinkApproved(t *testing.T) {
+    db, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+    }
+    defer db.Close()
+
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified, source FROM customer_ovo WHERE ovo_auth_id`).WithArgs("666").WillReturnError(sql.ErrNoRows)
+    rows := sqlmock.NewRows([]string{"customer_id", "ovo_phone", "ovo_auth_id", "reason", "actor", "source"}).AddRow(12345, "081234567890", "666", "phone_changed", "cs:andi", "hypermart")
+    mock.ExpectQuery(`SELECT customer_id, ovo_phone, ovo_auth_id, reason, actor, source FROM customer_ovo_relink WHERE ovo_auth_id`).WithArgs("666").WillReturnRows(rows)
+    mock.ExpectQuery(`SELECT customer_id, ovo_id, ovo_phone, ovo_auth_id, fg_verified, source FROM customer_ovo WHERE customer_id`).WithArgs(12345).WillReturnError(sql.ErrNoRows)
+    mock.ExpectBegin()
+    mock.ExpectExec(`INSERT INTO customer_ovo\(`).WithArgs(12345, "8000000000000666", "081234567890", "+6281234567890", "666", "hypermart").WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectExec(`DELETE FROM customer_ovo_relink`).WithArgs(12345).WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(`INSERT INTO customer_ovo_history`).WillReturnResult(sqlmock.NewResult(1, 1))
+    mock.ExpectCommit()
+
+    client := New("", "", "", "")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`{"status": 200, "data": {"loyalty_id": "8000000000000666"}, "message": "Authenticated", "code": 1}`))
+    }
+    webhook := client.GetMMsdk(db).NewWebhook(StaticKeys{"ovo": "secret"})
+
+    var received AuthenticationEvent
+    webhook.OnAuthentication(func(ev AuthenticationEvent) error {
+        received = ev
+        return nil
+    })
+
+    w := httptest.NewRecorder()
+    webhook.ServeHTTP(w, newNotification(t, "secret", `{"event":"authentication","code":1,"data":{"authentication_id":"666"}}`))
+
+    if w.Code != http.StatusOK {
+        t.Errorf("Notification should be accepted, got %d %s", w.Code, w.Body.String())
+    }
+    if received.CustomerOvo == nil || received.CustomerOvo.OvoPhone != "081234567890" || received.CustomerOvo.FgVerified != 1 {
+        t.Errorf("Handler should receive the relinked linkage, got %+v", received.CustomerOvo)
+    }
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Errorf("there were unfulfilled expectations: %s", err)
+    }
+}
+
+func TestWebhookReplayed(t *testing.T) {
+    client := New("", "", "", "")
+    client.httpHandler = func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(testTransactionStatus))
+    }
+    webhook := client.GetMMsdk(nil).NewWebhook(StaticKeys{"ovo": "secret"})
+    webhook.Verifier.Replay = NewMemoryReplayCache()
+
+    calls := 0
+    failing := true
+    webhook.OnTransaction(func(ev TransactionEvent) error {
+        calls++
+        if failing {
+            failing = false
+            return errors.New("storage unavailable")
+        }
+        return nil
+    })
+
+    body := `{"event":"transaction","code":2,"data":{"order_id":"T1","customer_phone":"081234567890"}}`
+    req := newNotification(t, "secret", body)
+    send := func(body string) int {
+        r := newNotification(t, "secret", body)
+        r.Header = req.Header
+        w := httptest.NewRecorder()
+        webhook.ServeHTTP(w, r)
+        return w.Code
+    }
+
+    if code := send(body); code != http.StatusInternalServerError {
+        t.Fatalf("Failed handler should answer 500, got %d", code)
+    }
+    if code := send(body); code != http.StatusOK || calls != 2 {
+        t.Errorf("Retry of a failed notification should be accepted, got %d", code)
+    }
+    if code := send(body); code != http.StatusUnauthorized || calls != 2 {
+        t.Errorf("Handled notification should be rejected when replayed, got %d", code)
+    }
+
+    other := `{"event":"transaction","code":2,"data":{"order_id":"T1","customer_phone":"081234567890","message":"second"}}`
+    if code := send(other); code != http.StatusOK || calls != 3 {
+        t.Errorf("Other notification signed within the same second should be accepted, got %d", code)
+    }
+}
+
+func TestWebhookBodyTooLarge(t *testing.T) {
+    client := New("", "", "", "")
+    webhook := client.GetMMsdk(nil).NewWebhook(StaticKeys{"ovo": "secret"})
+
+    body := `{"event":"transaction","message":"` + strings.Repeat("x", MaxNotificationBytes) + `"}`
+    w := httptest.NewRecorder()
+    webhook.ServeHTTP(w, newNotification(t, "secret", body))
+
+    if w.Code != http.StatusRequestEntityTooLarge {
+        t.Errorf("Body larger than MaxNotificationBytes should be rejected, got %d", w.Code)
+    }
+}